@@ -16,35 +16,148 @@
 package sample
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
 	"sync"
+	"text/tabwriter"
 	"time"
 
 	containeranalysis "cloud.google.com/go/containeranalysis/apiv1beta1"
 	pubsub "cloud.google.com/go/pubsub"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes"
+	gax "github.com/googleapis/gax-go/v2"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/oauth2/google"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/attestation"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/build"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/common"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/deployment"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/discovery"
 	grafeaspb "google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/grafeas"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/image"
+	pkg "google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/package"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/provenance"
 	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/vulnerability"
+	iampb "google.golang.org/genproto/googleapis/iam/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// [START new_client]
+
+// containerAnalysisClient combines the Grafeas client, which manages Notes and Occurrences, with the Container
+// Analysis client, which manages their IAM policies.
+type containerAnalysisClient struct {
+	*containeranalysis.GrafeasV1Beta1Client
+	*containeranalysis.ContainerAnalysisV1Beta1Client
+}
+
+// newContainerAnalysisClient creates a Container Analysis client. If endpoint is non-empty, the client connects to it
+// instead of the default endpoint, for example to reach the API through Private Service Connect or to use a test
+// emulator.
+func newContainerAnalysisClient(ctx context.Context, endpoint string) (*containerAnalysisClient, error) {
+	var opts []option.ClientOption
+	if endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint))
+	}
+	grafeas, err := containeranalysis.NewGrafeasV1Beta1Client(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	iam, err := containeranalysis.NewContainerAnalysisV1Beta1Client(ctx, opts...)
+	if err != nil {
+		grafeas.Close()
+		return nil, err
+	}
+	return &containerAnalysisClient{grafeas, iam}, nil
+}
+
+// Close closes the connections of both underlying clients.
+func (c *containerAnalysisClient) Close() error {
+	err := c.GrafeasV1Beta1Client.Close()
+	if iamErr := c.ContainerAnalysisV1Beta1Client.Close(); err == nil {
+		err = iamErr
+	}
+	return err
+}
+
+// [END new_client]
+
+// grafeasClient is the subset of *containerAnalysisClient that the samples use to manage Notes and Occurrences.
+// Accepting it instead of the concrete client lets the samples be tested with a fake.
+type grafeasClient interface {
+	CreateNote(ctx context.Context, req *grafeaspb.CreateNoteRequest, opts ...gax.CallOption) (*grafeaspb.Note, error)
+	GetNote(ctx context.Context, req *grafeaspb.GetNoteRequest, opts ...gax.CallOption) (*grafeaspb.Note, error)
+	UpdateNote(ctx context.Context, req *grafeaspb.UpdateNoteRequest, opts ...gax.CallOption) (*grafeaspb.Note, error)
+	DeleteNote(ctx context.Context, req *grafeaspb.DeleteNoteRequest, opts ...gax.CallOption) error
+	ListNotes(ctx context.Context, req *grafeaspb.ListNotesRequest, opts ...gax.CallOption) *containeranalysis.NoteIterator
+	ListNoteOccurrences(ctx context.Context, req *grafeaspb.ListNoteOccurrencesRequest, opts ...gax.CallOption) *containeranalysis.OccurrenceIterator
+
+	CreateOccurrence(ctx context.Context, req *grafeaspb.CreateOccurrenceRequest, opts ...gax.CallOption) (*grafeaspb.Occurrence, error)
+	BatchCreateOccurrences(ctx context.Context, req *grafeaspb.BatchCreateOccurrencesRequest, opts ...gax.CallOption) (*grafeaspb.BatchCreateOccurrencesResponse, error)
+	GetOccurrence(ctx context.Context, req *grafeaspb.GetOccurrenceRequest, opts ...gax.CallOption) (*grafeaspb.Occurrence, error)
+	GetOccurrenceNote(ctx context.Context, req *grafeaspb.GetOccurrenceNoteRequest, opts ...gax.CallOption) (*grafeaspb.Note, error)
+	UpdateOccurrence(ctx context.Context, req *grafeaspb.UpdateOccurrenceRequest, opts ...gax.CallOption) (*grafeaspb.Occurrence, error)
+	DeleteOccurrence(ctx context.Context, req *grafeaspb.DeleteOccurrenceRequest, opts ...gax.CallOption) error
+	ListOccurrences(ctx context.Context, req *grafeaspb.ListOccurrencesRequest, opts ...gax.CallOption) *containeranalysis.OccurrenceIterator
+	GetVulnerabilityOccurrencesSummary(ctx context.Context, req *grafeaspb.GetVulnerabilityOccurrencesSummaryRequest, opts ...gax.CallOption) (*grafeaspb.VulnerabilityOccurrencesSummary, error)
+}
+
+// iamPolicyClient is the subset of *containerAnalysisClient that the samples use to manage the IAM policies of Notes
+// and Occurrences. These methods belong to the Container Analysis API rather than to Grafeas.
+type iamPolicyClient interface {
+	GetIamPolicy(ctx context.Context, req *iampb.GetIamPolicyRequest, opts ...gax.CallOption) (*iampb.Policy, error)
+	SetIamPolicy(ctx context.Context, req *iampb.SetIamPolicyRequest, opts ...gax.CallOption) (*iampb.Policy, error)
+	TestIamPermissions(ctx context.Context, req *iampb.TestIamPermissionsRequest, opts ...gax.CallOption) (*iampb.TestIamPermissionsResponse, error)
+}
+
+// withTimeout returns a copy of ctx that is cancelled after timeout. If timeout is 0 or less, the copy is only
+// cancelled with ctx or by calling the returned cancel function.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 // [START create_note]
 
 // createNote creates and returns a new vulnerability Note.
-func createNote(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, noteID, projectID string) (*grafeaspb.Note, error) {
-	projectName := fmt.Sprintf("projects/%s", projectID)
+func createNote(ctx context.Context, client grafeasClient, noteID, projectID string) (*grafeaspb.Note, error) {
+	note := &grafeaspb.Note{
+		Type: &grafeaspb.Note_Vulnerability{
+			// The 'Vulnerability' field can be modified to contain information about your vulnerability.
+			Vulnerability: &vulnerability.Vulnerability{},
+		},
+	}
+	return createNoteWithType(ctx, client, noteID, projectID, note)
+}
 
+// createNoteWithType creates and returns a new Note of any kind, such as a build, deployment, attestation, image or
+// package Note. note must have its Type set.
+func createNoteWithType(ctx context.Context, client grafeasClient, noteID, projectID string, note *grafeaspb.Note) (*grafeaspb.Note, error) {
+	if note.GetType() == nil {
+		return nil, fmt.Errorf("note %s has no type", noteID)
+	}
 	req := &grafeaspb.CreateNoteRequest{
-		Parent: projectName,
+		Parent: fmt.Sprintf("projects/%s", projectID),
 		NoteId: noteID,
-		Note: &grafeaspb.Note{
-			Type: &grafeaspb.Note_Vulnerability{
-				// The 'Vulnerability' field can be modified to contain information about your vulnerability.
-				Vulnerability: &vulnerability.Vulnerability{},
-			},
-		},
+		Note:   note,
 	}
-
 	return client.CreateNote(ctx, req)
 }
 
@@ -53,8 +166,14 @@ func createNote(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Cli
 // [START create_occurrence]
 
 // createsOccurrence creates and returns a new Occurrence of a previously created vulnerability Note.
-func createOccurrence(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, imageURL, noteID, occProjectID, noteProjectID string) (*grafeaspb.Occurrence, error) {
-	req := &grafeaspb.CreateOccurrenceRequest{
+func createOccurrence(ctx context.Context, client grafeasClient, imageURL, noteID, occProjectID, noteProjectID string) (*grafeaspb.Occurrence, error) {
+	req := newOccurrenceRequest(imageURL, noteID, occProjectID, noteProjectID)
+	return client.CreateOccurrence(ctx, req)
+}
+
+// newOccurrenceRequest returns a request to create an Occurrence of a vulnerability Note for imageURL.
+func newOccurrenceRequest(imageURL, noteID, occProjectID, noteProjectID string) *grafeaspb.CreateOccurrenceRequest {
+	return &grafeaspb.CreateOccurrenceRequest{
 		Parent: fmt.Sprintf("projects/%s", occProjectID),
 		Occurrence: &grafeaspb.Occurrence{
 			NoteName: fmt.Sprintf("projects/%s/notes/%s", noteProjectID, noteID),
@@ -68,15 +187,434 @@ func createOccurrence(ctx context.Context, client *containeranalysis.GrafeasV1Be
 			},
 		},
 	}
-	return client.CreateOccurrence(ctx, req)
 }
 
 // [END create_occurrence]
 
+// [START batch_create_occurrences]
+
+// maxBatchOccurrences is the maximum number of Occurrences the API accepts in a single BatchCreateOccurrences request.
+const maxBatchOccurrences = 1000
+
+// batchCreateOccurrences creates and returns the given Occurrences using as few BatchCreateOccurrences requests as
+// possible. If a batch fails, the remaining batches are still attempted; the Occurrences that were created are
+// returned along with an error describing every failed batch.
+func batchCreateOccurrences(ctx context.Context, client grafeasClient, occProjectID string, occurrences []*grafeaspb.Occurrence) ([]*grafeaspb.Occurrence, error) {
+	var created []*grafeaspb.Occurrence
+	var errs []string
+	for start := 0; start < len(occurrences); start += maxBatchOccurrences {
+		end := start + maxBatchOccurrences
+		if end > len(occurrences) {
+			end = len(occurrences)
+		}
+		req := &grafeaspb.BatchCreateOccurrencesRequest{
+			Parent:      fmt.Sprintf("projects/%s", occProjectID),
+			Occurrences: occurrences[start:end],
+		}
+		resp, err := client.BatchCreateOccurrences(ctx, req)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("occurrences %d-%d: %v", start, end-1, err))
+			continue
+		}
+		created = append(created, resp.Occurrences...)
+	}
+	if len(errs) > 0 {
+		return created, fmt.Errorf("BatchCreateOccurrences failed for %d batch(es): %s", len(errs), strings.Join(errs, "; "))
+	}
+	return created, nil
+}
+
+// [END batch_create_occurrences]
+
+// [START create_occurrence_with_retry]
+
+// createOccurrenceBackoff controls the delay between createOccurrenceWithRetry attempts.
+// gax.Backoff adds jitter to each pause.
+var createOccurrenceBackoff = gax.Backoff{
+	Initial:    500 * time.Millisecond,
+	Max:        16 * time.Second,
+	Multiplier: 2,
+}
+
+// createOccurrenceWithRetry creates and returns a new Occurrence of a previously created vulnerability Note,
+// retrying transient failures with exponential backoff up to maxAttempts times in total. maxAttempts must be at
+// least 1. Only Unavailable and DeadlineExceeded errors are retried; any other error is returned immediately.
+// The request is built by newOccurrenceRequest, so it matches the one sent by createOccurrence.
+func createOccurrenceWithRetry(ctx context.Context, client grafeasClient, imageURL, noteID, occProjectID, noteProjectID string, maxAttempts int) (*grafeaspb.Occurrence, error) {
+	if maxAttempts < 1 {
+		return nil, fmt.Errorf("maxAttempts is %d; must be at least 1", maxAttempts)
+	}
+	req := newOccurrenceRequest(imageURL, noteID, occProjectID, noteProjectID)
+	backoff := createOccurrenceBackoff
+	for attempt := 1; ; attempt++ {
+		occ, err := client.CreateOccurrence(ctx, req)
+		if err == nil {
+			return occ, nil
+		}
+		switch status.Code(err) {
+		case codes.Unavailable, codes.DeadlineExceeded:
+		default:
+			return nil, err
+		}
+		if attempt >= maxAttempts {
+			return nil, fmt.Errorf("CreateOccurrence failed after %d attempts: %v", attempt, err)
+		}
+		if err := gax.Sleep(ctx, backoff.Pause()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// [END create_occurrence_with_retry]
+
+// [START create_attestation_occurrence]
+
+// createAttestationOccurrence creates and returns a new Occurrence of a previously created attestation authority Note,
+// recording that imageURL was signed. signature is an ASCII-armored PGP signature of the image's signing payload,
+// as produced by "gpg --armor --sign", and publicKeyID is the fingerprint of the key that produced it.
+func createAttestationOccurrence(ctx context.Context, client grafeasClient, imageURL, noteID, occProjectID, noteProjectID string, signature []byte, publicKeyID string) (*grafeaspb.Occurrence, error) {
+	if len(signature) == 0 {
+		return nil, fmt.Errorf("signature for %s is empty", imageURL)
+	}
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: fmt.Sprintf("projects/%s", occProjectID),
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: fmt.Sprintf("projects/%s/notes/%s", noteProjectID, noteID),
+			// Attach the occurrence to the associated image uri.
+			Resource: &grafeaspb.Resource{
+				Uri: imageURL,
+			},
+			Details: &grafeaspb.Occurrence_Attestation{
+				Attestation: &attestation.Details{
+					Attestation: &attestation.Attestation{
+						Signature: &attestation.Attestation_PgpSignedAttestation{
+							PgpSignedAttestation: &attestation.PgpSignedAttestation{
+								Signature:   string(signature),
+								ContentType: attestation.PgpSignedAttestation_SIMPLE_SIGNING_JSON,
+								KeyId: &attestation.PgpSignedAttestation_PgpKeyId{
+									PgpKeyId: publicKeyID,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return client.CreateOccurrence(ctx, req)
+}
+
+// [END create_attestation_occurrence]
+
+// [START verify_attestation_occurrence]
+
+// verifyAttestationOccurrence retrieves an attestation Occurrence and verifies its PGP signature
+// against publicKey, an ASCII-armored PGP public key.
+// It returns false and an error describing the problem if the signature is not valid.
+func verifyAttestationOccurrence(ctx context.Context, client grafeasClient, occurrenceName string, publicKey []byte) (bool, error) {
+	occ, err := client.GetOccurrence(ctx, &grafeaspb.GetOccurrenceRequest{Name: occurrenceName})
+	if err != nil {
+		return false, err
+	}
+	pgp := occ.GetAttestation().GetAttestation().GetPgpSignedAttestation()
+	if pgp == nil {
+		return false, fmt.Errorf("%s is not a PGP signed attestation", occurrenceName)
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(publicKey))
+	if err != nil {
+		return false, fmt.Errorf("failed to read public key: %v", err)
+	}
+	block, err := armor.Decode(strings.NewReader(pgp.Signature))
+	if err != nil {
+		return false, fmt.Errorf("signature on %s is not ASCII-armored: %v", occurrenceName, err)
+	}
+	md, err := openpgp.ReadMessage(block.Body, keyring, nil, nil)
+	if err != nil {
+		return false, fmt.Errorf("signature on %s is malformed: %v", occurrenceName, err)
+	}
+	// The serialized payload must be read in full before the signature is checked.
+	payload, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return false, fmt.Errorf("signature on %s is malformed: %v", occurrenceName, err)
+	}
+	switch {
+	case !md.IsSigned:
+		return false, fmt.Errorf("%s contains an unsigned payload", occurrenceName)
+	case md.SignedBy == nil:
+		return false, fmt.Errorf("%s was signed by key %X, which does not match the public key", occurrenceName, md.SignedByKeyId)
+	case md.SignatureError != nil:
+		return false, fmt.Errorf("signature on %s does not match its %d-byte payload", occurrenceName, len(payload))
+	}
+	return true, nil
+}
+
+// [END verify_attestation_occurrence]
+
+// [START create_build_note]
+
+// createBuildNote creates and returns a new build Note describing the builder that produced images.
+func createBuildNote(ctx context.Context, client grafeasClient, noteID, projectID, builderVersion string) (*grafeaspb.Note, error) {
+	note := &grafeaspb.Note{
+		Type: &grafeaspb.Note_Build{
+			Build: &build.Build{
+				BuilderVersion: builderVersion,
+			},
+		},
+	}
+	return createNoteWithType(ctx, client, noteID, projectID, note)
+}
+
+// [END create_build_note]
+
+// [START create_build_occurrence]
+
+// createBuildOccurrence creates and returns a new Occurrence of a previously created build Note,
+// attaching the provenance of how imageURL was built.
+// The provenance must have an ID, at least one built artifact, and a source provenance.
+func createBuildOccurrence(ctx context.Context, client grafeasClient, imageURL, noteID, occProjectID, noteProjectID string, buildProvenance *provenance.BuildProvenance) (*grafeaspb.Occurrence, error) {
+	switch {
+	case buildProvenance == nil:
+		return nil, fmt.Errorf("build provenance for %s is nil", imageURL)
+	case buildProvenance.Id == "":
+		return nil, fmt.Errorf("build provenance for %s has no ID", imageURL)
+	case len(buildProvenance.BuiltArtifacts) == 0:
+		return nil, fmt.Errorf("build provenance for %s has no built artifacts", imageURL)
+	case buildProvenance.SourceProvenance == nil:
+		return nil, fmt.Errorf("build provenance for %s has no source provenance", imageURL)
+	}
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: fmt.Sprintf("projects/%s", occProjectID),
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: fmt.Sprintf("projects/%s/notes/%s", noteProjectID, noteID),
+			// Attach the occurrence to the associated image uri.
+			Resource: &grafeaspb.Resource{
+				Uri: imageURL,
+			},
+			Details: &grafeaspb.Occurrence_Build{
+				Build: &build.Details{
+					Provenance: buildProvenance,
+				},
+			},
+		},
+	}
+	return client.CreateOccurrence(ctx, req)
+}
+
+// [END create_build_occurrence]
+
+// [START create_deployment_note]
+
+// createDeploymentNote creates and returns a new deployable Note for the resources in resourceURIs.
+func createDeploymentNote(ctx context.Context, client grafeasClient, noteID, projectID string, resourceURIs []string) (*grafeaspb.Note, error) {
+	note := &grafeaspb.Note{
+		Type: &grafeaspb.Note_Deployable{
+			Deployable: &deployment.Deployable{
+				ResourceUri: resourceURIs,
+			},
+		},
+	}
+	return createNoteWithType(ctx, client, noteID, projectID, note)
+}
+
+// [END create_deployment_note]
+
+// [START create_deployment_occurrence]
+
+// createDeploymentOccurrence creates and returns a new Occurrence of a previously created deployable Note,
+// recording that imageURL was deployed to platform at deployTime.
+func createDeploymentOccurrence(ctx context.Context, client grafeasClient, imageURL, noteID, occProjectID, noteProjectID string, deployTime time.Time, platform deployment.Deployment_Platform) (*grafeaspb.Occurrence, error) {
+	if deployTime.IsZero() {
+		return nil, fmt.Errorf("deploy time for %s is not set", imageURL)
+	}
+	ts, err := ptypes.TimestampProto(deployTime)
+	if err != nil {
+		return nil, err
+	}
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: fmt.Sprintf("projects/%s", occProjectID),
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: fmt.Sprintf("projects/%s/notes/%s", noteProjectID, noteID),
+			// Attach the occurrence to the associated image uri.
+			Resource: &grafeaspb.Resource{
+				Uri: imageURL,
+			},
+			Details: &grafeaspb.Occurrence_Deployment{
+				Deployment: &deployment.Details{
+					Deployment: &deployment.Deployment{
+						DeployTime: ts,
+						Platform:   platform,
+					},
+				},
+			},
+		},
+	}
+	return client.CreateOccurrence(ctx, req)
+}
+
+// [END create_deployment_occurrence]
+
+// [START create_image_note]
+
+// createImageNote creates and returns a new base image Note for the image at baseImageURL.
+// fingerprint identifies the base image's layers, and its V1Name must be set.
+func createImageNote(ctx context.Context, client grafeasClient, noteID, projectID, baseImageURL string, fingerprint *image.Fingerprint) (*grafeaspb.Note, error) {
+	if fingerprint.GetV1Name() == "" {
+		return nil, fmt.Errorf("fingerprint for %s has no V1Name", baseImageURL)
+	}
+	note := &grafeaspb.Note{
+		Type: &grafeaspb.Note_BaseImage{
+			BaseImage: &image.Basis{
+				ResourceUrl: baseImageURL,
+				Fingerprint: fingerprint,
+			},
+		},
+	}
+	return createNoteWithType(ctx, client, noteID, projectID, note)
+}
+
+// [END create_image_note]
+
+// [START create_image_occurrence]
+
+// createImageOccurrence creates and returns a new Occurrence of a previously created base image Note,
+// recording that imageURL derives from that base image.
+// fingerprint identifies imageURL's layers, and its V1Name must be set. layers describes the layers added on top
+// of the base image.
+func createImageOccurrence(ctx context.Context, client grafeasClient, imageURL, noteID, occProjectID, noteProjectID string, fingerprint *image.Fingerprint, layers []*image.Layer) (*grafeaspb.Occurrence, error) {
+	if fingerprint.GetV1Name() == "" {
+		return nil, fmt.Errorf("fingerprint for %s has no V1Name", imageURL)
+	}
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: fmt.Sprintf("projects/%s", occProjectID),
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: fmt.Sprintf("projects/%s/notes/%s", noteProjectID, noteID),
+			// Attach the occurrence to the associated image uri.
+			Resource: &grafeaspb.Resource{
+				Uri: imageURL,
+			},
+			Details: &grafeaspb.Occurrence_DerivedImage{
+				DerivedImage: &image.Details{
+					DerivedImage: &image.Derived{
+						Fingerprint: fingerprint,
+						LayerInfo:   layers,
+					},
+				},
+			},
+		},
+	}
+	return client.CreateOccurrence(ctx, req)
+}
+
+// [END create_image_occurrence]
+
+// [START create_package_note]
+
+// createPackageNote creates and returns a new package Note for packageName, listing the distributions it is
+// available for.
+func createPackageNote(ctx context.Context, client grafeasClient, noteID, projectID, packageName string, distributions []*pkg.Distribution) (*grafeaspb.Note, error) {
+	if packageName == "" {
+		return nil, fmt.Errorf("package note %s has no package name", noteID)
+	}
+	note := &grafeaspb.Note{
+		Type: &grafeaspb.Note_Package{
+			Package: &pkg.Package{
+				Name:         packageName,
+				Distribution: distributions,
+			},
+		},
+	}
+	return createNoteWithType(ctx, client, noteID, projectID, note)
+}
+
+// [END create_package_note]
+
+// [START create_package_occurrence]
+
+// createPackageOccurrence creates and returns a new Occurrence of a previously created package Note, recording that
+// packageName is installed in imageURL at location. location.Version must have its Name set.
+func createPackageOccurrence(ctx context.Context, client grafeasClient, imageURL, noteID, occProjectID, noteProjectID, packageName string, location *pkg.Location) (*grafeaspb.Occurrence, error) {
+	if packageName == "" {
+		return nil, fmt.Errorf("package occurrence for %s has no package name", imageURL)
+	}
+	if location.GetVersion().GetName() == "" {
+		return nil, fmt.Errorf("package %s installed in %s has no version", packageName, imageURL)
+	}
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: fmt.Sprintf("projects/%s", occProjectID),
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: fmt.Sprintf("projects/%s/notes/%s", noteProjectID, noteID),
+			// Attach the occurrence to the associated image uri.
+			Resource: &grafeaspb.Resource{
+				Uri: imageURL,
+			},
+			Details: &grafeaspb.Occurrence_Installation{
+				Installation: &pkg.Details{
+					Installation: &pkg.Installation{
+						Name:     packageName,
+						Location: []*pkg.Location{location},
+					},
+				},
+			},
+		},
+	}
+	return client.CreateOccurrence(ctx, req)
+}
+
+// [END create_package_occurrence]
+
+// [START create_discovery_note]
+
+// createDiscoveryNote creates and returns a new discovery Note for a scanner that performs analysis of the given
+// kind, such as common.NoteKind_VULNERABILITY.
+func createDiscoveryNote(ctx context.Context, client grafeasClient, noteID, projectID string, analysisKind common.NoteKind) (*grafeaspb.Note, error) {
+	note := &grafeaspb.Note{
+		Type: &grafeaspb.Note_Discovery{
+			Discovery: &discovery.Discovery{
+				AnalysisKind: analysisKind,
+			},
+		},
+	}
+	return createNoteWithType(ctx, client, noteID, projectID, note)
+}
+
+// [END create_discovery_note]
+
+// [START create_discovery_occurrence]
+
+// createDiscoveryOccurrence creates and returns a new Occurrence of a previously created discovery Note, recording
+// the progress of a scan of imageURL. The returned Occurrence can later be updated as the scan progresses.
+func createDiscoveryOccurrence(ctx context.Context, client grafeasClient, imageURL, noteID, occProjectID, noteProjectID string, status discovery.Discovered_AnalysisStatus, continuousAnalysis discovery.Discovered_ContinuousAnalysis) (*grafeaspb.Occurrence, error) {
+	if _, ok := discovery.Discovered_AnalysisStatus_name[int32(status)]; !ok || status == discovery.Discovered_ANALYSIS_STATUS_UNSPECIFIED {
+		return nil, fmt.Errorf("invalid analysis status %d", status)
+	}
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: fmt.Sprintf("projects/%s", occProjectID),
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: fmt.Sprintf("projects/%s/notes/%s", noteProjectID, noteID),
+			// Attach the occurrence to the associated image uri.
+			Resource: &grafeaspb.Resource{
+				Uri: imageURL,
+			},
+			Details: &grafeaspb.Occurrence_Discovered{
+				Discovered: &discovery.Details{
+					Discovered: &discovery.Discovered{
+						AnalysisStatus:     status,
+						ContinuousAnalysis: continuousAnalysis,
+					},
+				},
+			},
+		},
+	}
+	return client.CreateOccurrence(ctx, req)
+}
+
+// [END create_discovery_occurrence]
+
 // [START update_note]
 
 // updateNote pushes an update to a Note that already exists on the server.
-func updateNote(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, updated *grafeaspb.Note, noteID, projectID string) (*grafeaspb.Note, error) {
+func updateNote(ctx context.Context, client grafeasClient, updated *grafeaspb.Note, noteID, projectID string) (*grafeaspb.Note, error) {
 	req := &grafeaspb.UpdateNoteRequest{
 		Name: fmt.Sprintf("projects/%s/notes/%s", projectID, noteID),
 		Note: updated,
@@ -90,7 +628,7 @@ func updateNote(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Cli
 
 // updateOccurrences pushes an update to an Occurrence that already exists on the server.
 // occurrenceName should be in the following format: "projects/[PROJECT_ID]/occurrences/[OCCURRENCE_ID]"
-func updateOccurrence(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, updated *grafeaspb.Occurrence, occurrenceName string) (*grafeaspb.Occurrence, error) {
+func updateOccurrence(ctx context.Context, client grafeasClient, updated *grafeaspb.Occurrence, occurrenceName string) (*grafeaspb.Occurrence, error) {
 	req := &grafeaspb.UpdateOccurrenceRequest{
 		Name:       occurrenceName,
 		Occurrence: updated,
@@ -100,10 +638,59 @@ func updateOccurrence(ctx context.Context, client *containeranalysis.GrafeasV1Be
 
 // [END update_occurrence]
 
+// [START update_discovery_occurrence_status]
+
+// discoveryStatusTransitions lists the analysis statuses a discovery Occurrence may move to from each status.
+// Finished statuses are final.
+var discoveryStatusTransitions = map[discovery.Discovered_AnalysisStatus][]discovery.Discovered_AnalysisStatus{
+	discovery.Discovered_PENDING: {
+		discovery.Discovered_SCANNING,
+		discovery.Discovered_FINISHED_SUCCESS,
+		discovery.Discovered_FINISHED_FAILED,
+		discovery.Discovered_FINISHED_UNSUPPORTED,
+	},
+	discovery.Discovered_SCANNING: {
+		discovery.Discovered_FINISHED_SUCCESS,
+		discovery.Discovered_FINISHED_FAILED,
+		discovery.Discovered_FINISHED_UNSUPPORTED,
+	},
+}
+
+// updateDiscoveryOccurrenceStatus sets the analysis status of a discovery Occurrence, for example to report that a
+// scan moved from PENDING to SCANNING to FINISHED_SUCCESS, and returns the updated Occurrence.
+// occurrenceName should be in the following format: "projects/[PROJECT_ID]/occurrences/[OCCURRENCE_ID]"
+func updateDiscoveryOccurrenceStatus(ctx context.Context, client grafeasClient, occurrenceName string, status discovery.Discovered_AnalysisStatus) (*grafeaspb.Occurrence, error) {
+	occ, err := client.GetOccurrence(ctx, &grafeaspb.GetOccurrenceRequest{Name: occurrenceName})
+	if err != nil {
+		return nil, err
+	}
+	discovered := occ.GetDiscovered().GetDiscovered()
+	if discovered == nil {
+		return nil, fmt.Errorf("occurrence %s is not a discovery occurrence", occurrenceName)
+	}
+
+	current := discovered.AnalysisStatus
+	allowed := false
+	for _, next := range discoveryStatusTransitions[current] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("occurrence %s can't move from %v to %v", occurrenceName, current, status)
+	}
+
+	discovered.AnalysisStatus = status
+	return updateOccurrence(ctx, client, occ, occurrenceName)
+}
+
+// [END update_discovery_occurrence_status]
+
 // [START delete_note]
 
 // deleteNote removes an existing Note from the server.
-func deleteNote(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, noteID, projectID string) error {
+func deleteNote(ctx context.Context, client grafeasClient, noteID, projectID string) error {
 	req := &grafeaspb.DeleteNoteRequest{
 		Name: fmt.Sprintf("projects/%s/notes/%s", projectID, noteID),
 	}
@@ -116,22 +703,59 @@ func deleteNote(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Cli
 
 // deleteOccurrence removes an existing Occurrence from the server.
 // occurrenceName should be in the following format: "projects/[PROJECT_ID]/occurrences/[OCCURRENCE_ID]"
-func deleteOccurrence(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, occurrenceName string) error {
+func deleteOccurrence(ctx context.Context, client grafeasClient, occurrenceName string) error {
 	req := &grafeaspb.DeleteOccurrenceRequest{Name: occurrenceName}
 	return client.DeleteOccurrence(ctx, req)
 }
 
 // [END delete_occurrence]
 
+// [START purge_occurrences_for_image]
+
+// purgeOccurrencesForImage deletes every Occurrence associated with a specified image, for example when the image
+// is decommissioned, and returns how many were deleted. If an Occurrence can't be deleted, the rest are still
+// attempted and an error describing every failure is returned along with the count.
+func purgeOccurrencesForImage(ctx context.Context, client grafeasClient, imageURL, projectID string) (int, error) {
+	// Collect the names first so that deleting doesn't disturb the pagination.
+	var names []string
+	err := streamOccurrencesForImage(ctx, client, imageURL, projectID, 0, func(occ *grafeaspb.Occurrence) error {
+		names = append(names, occ.Name)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	var errs []string
+	for _, name := range names {
+		req := &grafeaspb.DeleteOccurrenceRequest{Name: name}
+		if err := client.DeleteOccurrence(ctx, req); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		deleted++
+	}
+	if len(errs) > 0 {
+		return deleted, fmt.Errorf("DeleteOccurrence failed for %d occurrence(s): %s", len(errs), strings.Join(errs, "; "))
+	}
+	return deleted, nil
+}
+
+// [END purge_occurrences_for_image]
+
 // [START get_note]
 
-// getNote retrieves and prints a specified Note from the server.
-func getNote(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, noteID, projectID string) (*grafeaspb.Note, error) {
+// getNote retrieves a specified Note from the server and prints it to w.
+// timeout bounds the GetNote request; if it is 0 or less, only ctx bounds it.
+func getNote(ctx context.Context, w io.Writer, client grafeasClient, noteID, projectID string, timeout time.Duration) (*grafeaspb.Note, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
 	req := &grafeaspb.GetNoteRequest{
 		Name: fmt.Sprintf("projects/%s/notes/%s", projectID, noteID),
 	}
 	note, err := client.GetNote(ctx, req)
-	fmt.Println(note)
+	fmt.Fprintln(w, note)
 	return note, err
 }
 
@@ -139,141 +763,1377 @@ func getNote(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client
 
 // [START get_occurrence]
 
-// getOccurrence retrieves and prints a specified Occurrence from the server.
+// getOccurrence retrieves a specified Occurrence from the server and prints it to w.
 // occurrenceName should be in the following format: "projects/[PROJECT_ID]/occurrences/[OCCURRENCE_ID]"
-func getOccurrence(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, occurrenceName string) (*grafeaspb.Occurrence, error) {
+// timeout bounds the GetOccurrence request; if it is 0 or less, only ctx bounds it.
+func getOccurrence(ctx context.Context, w io.Writer, client grafeasClient, occurrenceName string, timeout time.Duration) (*grafeaspb.Occurrence, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
 	req := &grafeaspb.GetOccurrenceRequest{Name: occurrenceName}
 	occ, err := client.GetOccurrence(ctx, req)
-	fmt.Println(occ)
+	fmt.Fprintln(w, occ)
 	return occ, err
 }
 
 // [END get_occurrence]
 
-// [START discovery_info]
+// [START get_occurrence_note]
 
-// getDiscoveryInfo retrieves and prints the Discovery Occurrence created for a specified image.
-// The Discovery Occurrence contains information about the initial scan on the image.
-func getDiscoveryInfo(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, imageURL, projectID string) error {
-	req := &grafeaspb.ListOccurrencesRequest{
-		Parent: fmt.Sprintf("projects/%s", projectID),
-		Filter: fmt.Sprintf(`kind="DISCOVERY" AND resourceUrl=%q`, imageURL),
+// getNoteForOccurrence retrieves the Note that a specified Occurrence is an instance of, for example to read the
+// upstream vulnerability details of a finding.
+// occurrenceName should be in the following format: "projects/[PROJECT_ID]/occurrences/[OCCURRENCE_ID]"
+func getNoteForOccurrence(ctx context.Context, client grafeasClient, occurrenceName string) (*grafeaspb.Note, error) {
+	req := &grafeaspb.GetOccurrenceNoteRequest{Name: occurrenceName}
+	note, err := client.GetOccurrenceNote(ctx, req)
+	if status.Code(err) == codes.NotFound {
+		return nil, fmt.Errorf("no note found for occurrence %s; the occurrence or its note may have been deleted: %v", occurrenceName, err)
 	}
-	it := client.ListOccurrences(ctx, req)
-	for {
-		occ, err := it.Next()
+	return note, err
+}
+
+// [END get_occurrence_note]
+
+// [START list_notes]
+
+// listNotes retrieves and returns all the Notes in a specified project.
+// filter optionally restricts the Notes returned, and pageSize optionally sets how many Notes are fetched per request.
+// Pass "" and 0 to use the defaults.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func listNotes(ctx context.Context, client grafeasClient, projectID, filter string, pageSize int32, timeout time.Duration) ([]*grafeaspb.Note, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	req := &grafeaspb.ListNotesRequest{
+		Parent:   fmt.Sprintf("projects/%s", projectID),
+		Filter:   filter,
+		PageSize: pageSize,
+	}
+	it := client.ListNotes(ctx, req)
+	var notes []*grafeaspb.Note
+	for {
+		note, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// [END list_notes]
+
+// [START note_iam_policy]
+
+// getNoteIamPolicy retrieves the IAM policy of a specified Note and prints it to w.
+func getNoteIamPolicy(ctx context.Context, w io.Writer, client iamPolicyClient, noteID, projectID string) (*iampb.Policy, error) {
+	req := &iampb.GetIamPolicyRequest{
+		Resource: fmt.Sprintf("projects/%s/notes/%s", projectID, noteID),
+	}
+	policy, err := client.GetIamPolicy(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(w, policy)
+	return policy, nil
+}
+
+// setNoteIamPolicy grants role to member on a specified Note and prints the resulting policy to w.
+// For example, granting "roles/containeranalysis.notes.attacher" lets another project attach Occurrences to the Note.
+// The policy is left unchanged if member already has role.
+func setNoteIamPolicy(ctx context.Context, w io.Writer, client iamPolicyClient, noteID, projectID, role, member string) (*iampb.Policy, error) {
+	resource := fmt.Sprintf("projects/%s/notes/%s", projectID, noteID)
+	policy, err := client.GetIamPolicy(ctx, &iampb.GetIamPolicyRequest{Resource: resource})
+	if err != nil {
+		return nil, err
+	}
+
+	var binding *iampb.Binding
+	for _, b := range policy.Bindings {
+		if b.Role == role {
+			binding = b
+			break
+		}
+	}
+	if binding == nil {
+		binding = &iampb.Binding{Role: role}
+		policy.Bindings = append(policy.Bindings, binding)
+	}
+	for _, m := range binding.Members {
+		if m == member {
+			fmt.Fprintln(w, policy)
+			return policy, nil
+		}
+	}
+	binding.Members = append(binding.Members, member)
+
+	// The policy still carries the Etag returned by GetIamPolicy, so SetIamPolicy fails
+	// rather than overwriting the policy if it was modified concurrently.
+	req := &iampb.SetIamPolicyRequest{
+		Resource: resource,
+		Policy:   policy,
+	}
+	updated, err := client.SetIamPolicy(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(w, updated)
+	return updated, nil
+}
+
+// [END note_iam_policy]
+
+// [START test_iam_permissions]
+
+// testNotePermissions returns the subset of permissions, such as "containeranalysis.notes.attachOccurrence", that the
+// caller holds on a specified Note, and prints them to w.
+func testNotePermissions(ctx context.Context, w io.Writer, client iamPolicyClient, noteID, projectID string, permissions []string) ([]string, error) {
+	return testPermissions(ctx, w, client, fmt.Sprintf("projects/%s/notes/%s", projectID, noteID), permissions)
+}
+
+// testOccurrencePermissions returns the subset of permissions, such as "containeranalysis.occurrences.update", that
+// the caller holds on a specified Occurrence, and prints them to w.
+// occurrenceName should be in the following format: "projects/[PROJECT_ID]/occurrences/[OCCURRENCE_ID]"
+func testOccurrencePermissions(ctx context.Context, w io.Writer, client iamPolicyClient, occurrenceName string, permissions []string) ([]string, error) {
+	return testPermissions(ctx, w, client, occurrenceName, permissions)
+}
+
+func testPermissions(ctx context.Context, w io.Writer, client iamPolicyClient, resource string, permissions []string) ([]string, error) {
+	req := &iampb.TestIamPermissionsRequest{
+		Resource:    resource,
+		Permissions: permissions,
+	}
+	resp, err := client.TestIamPermissions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(w, "Granted on %s: %v\n", resource, resp.Permissions)
+	return resp.Permissions, nil
+}
+
+// [END test_iam_permissions]
+
+// [START discovery_info]
+
+// getDiscoveryInfo retrieves the Discovery Occurrence created for a specified image and prints it to w.
+// The Discovery Occurrence contains information about the initial scan on the image.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func getDiscoveryInfo(ctx context.Context, w io.Writer, client grafeasClient, imageURL, projectID string, timeout time.Duration) error {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf(`kind="DISCOVERY" AND resourceUrl=%q`, imageURL),
+	}
+	it := client.ListOccurrences(ctx, req)
+	for {
+		occ, err := it.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
 			return err
 		}
-		fmt.Println(occ)
+		fmt.Fprintln(w, occ)
 	}
 	return nil
 }
 
 // [END discovery_info]
 
+// [START poll_discovery_occurrence_finished]
+
+// pollDiscoveryOccurrenceFinished waits until the Discovery Occurrence for a specified image reaches a terminal
+// analysis status, and returns it. An error is returned if this doesn't happen within timeout, or before ctx is
+// done; if timeout is 0 or less, only ctx bounds the wait.
+func pollDiscoveryOccurrenceFinished(ctx context.Context, client grafeasClient, imageURL, projectID string, timeout time.Duration) (*grafeaspb.Occurrence, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	// Poll right away, then once per second.
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	wait := func() error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			return nil
+		}
+	}
+
+	// Find the Discovery Occurrence. It may not exist until the image has been scanned for the first time.
+	var discoveryOccurrence *grafeaspb.Occurrence
+	for {
+		req := &grafeaspb.ListOccurrencesRequest{
+			Parent: fmt.Sprintf("projects/%s", projectID),
+			Filter: fmt.Sprintf(`kind="DISCOVERY" AND resourceUrl=%q`, imageURL),
+		}
+		it := client.ListOccurrences(ctx, req)
+		// Only one Occurrence should ever be returned by ListOccurrences and the given filter.
+		occ, err := it.Next()
+		if err != nil && err != iterator.Done {
+			return nil, err
+		}
+		if err == nil && occ.GetDiscovered() != nil {
+			discoveryOccurrence = occ
+			break
+		}
+		if err := wait(); err != nil {
+			return nil, fmt.Errorf("retrieving discovery occurrence for %s: %v", imageURL, err)
+		}
+	}
+
+	// Wait for the Discovery Occurrence to enter a terminal state.
+	for {
+		switch discoveryOccurrence.GetDiscovered().GetDiscovered().GetAnalysisStatus() {
+		case discovery.Discovered_FINISHED_SUCCESS, discovery.Discovered_FINISHED_FAILED, discovery.Discovered_FINISHED_UNSUPPORTED:
+			return discoveryOccurrence, nil
+		}
+		if err := wait(); err != nil {
+			return nil, fmt.Errorf("waiting for %s to reach a terminal state: %v", discoveryOccurrence.Name, err)
+		}
+		req := &grafeaspb.GetOccurrenceRequest{Name: discoveryOccurrence.Name}
+		occ, err := client.GetOccurrence(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		discoveryOccurrence = occ
+	}
+}
+
+// [END poll_discovery_occurrence_finished]
+
+// [START poll_vulnerabilities_found]
+
+// pollUntilVulnerabilitiesFound waits until at least one vulnerability Occurrence exists for a specified image,
+// and returns all the vulnerability Occurrences found. Use it after pushing an image to avoid racing the scanner.
+// An error is returned if no vulnerabilities are found within timeout or ctx is cancelled. If timeout is 0 or
+// less, only ctx bounds the wait.
+func pollUntilVulnerabilitiesFound(ctx context.Context, client grafeasClient, imageURL, projectID string, timeout time.Duration) ([]*grafeaspb.Occurrence, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	// Poll once per second.
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf(`kind="VULNERABILITY" AND resourceUrl=%q`, imageURL),
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no vulnerabilities found for %s: %v", imageURL, ctx.Err())
+		case <-ticker.C:
+			it := client.ListOccurrences(ctx, req)
+			var occs []*grafeaspb.Occurrence
+			for {
+				occ, err := it.Next()
+				if err == iterator.Done {
+					break
+				}
+				if err != nil {
+					return nil, err
+				}
+				occs = append(occs, occ)
+			}
+			if len(occs) > 0 {
+				return occs, nil
+			}
+		}
+	}
+}
+
+// [END poll_vulnerabilities_found]
+
 // [START occurrences_for_note]
 
-// getOccurrencesForNote retrieves all the Occurrences associated with a specified Note.
-// Here, all Occurrences are printed and counted.
-func getOccurrencesForNote(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, noteID, projectID string) (int, error) {
+// getOccurrencesForNote retrieves and returns all the Occurrences associated with a specified Note.
+// Here, all Occurrences are also printed to w.
+// pageSize sets how many Occurrences are fetched per request; 0 uses the server default.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func getOccurrencesForNote(ctx context.Context, w io.Writer, client grafeasClient, noteID, projectID string, pageSize int32, timeout time.Duration) ([]*grafeaspb.Occurrence, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
 	req := &grafeaspb.ListNoteOccurrencesRequest{
-		Name: fmt.Sprintf("projects/%s/notes/%s", projectID, noteID),
+		Name:     fmt.Sprintf("projects/%s/notes/%s", projectID, noteID),
+		PageSize: pageSize,
 	}
 	it := client.ListNoteOccurrences(ctx, req)
-	count := 0
+	var occs []*grafeaspb.Occurrence
 	for {
 		occ, err := it.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return -1, err
+			return nil, err
 		}
 		// Write custom code to process each Occurrence here.
-		fmt.Println(occ)
-		count = count + 1
+		fmt.Fprintln(w, occ)
+		occs = append(occs, occ)
+	}
+	return occs, nil
+}
+
+// getOccurrencesForNoteAcrossProjects counts the Occurrences of a shared Note in each of occurrenceProjectIDs,
+// and returns the total. The Note's Occurrences are listed once with ListNoteOccurrences, so the caller needs
+// permission to list the Note's Occurrences rather than read access to each consumer project. Occurrences in
+// projects that aren't in occurrenceProjectIDs aren't counted. If the listing fails part way, the partial total is
+// returned along with an error giving the count reached in each project.
+// timeout bounds the time spent listing the Occurrences; if it is 0 or less, only ctx bounds it.
+func getOccurrencesForNoteAcrossProjects(ctx context.Context, client grafeasClient, noteID, noteProjectID string, occurrenceProjectIDs []string, timeout time.Duration) (int, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	counts := make(map[string]int)
+	for _, projectID := range occurrenceProjectIDs {
+		counts[projectID] = 0
+	}
+	req := &grafeaspb.ListNoteOccurrencesRequest{
+		Name: fmt.Sprintf("projects/%s/notes/%s", noteProjectID, noteID),
 	}
-	return count, nil
+	it := client.ListNoteOccurrences(ctx, req)
+	total := 0
+	for {
+		occ, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			var partial []string
+			for _, projectID := range occurrenceProjectIDs {
+				partial = append(partial, fmt.Sprintf("%s: %d", projectID, counts[projectID]))
+			}
+			return total, fmt.Errorf("ListNoteOccurrences failed with partial counts (%s): %v", strings.Join(partial, ", "), err)
+		}
+		// Occurrence names have the form "projects/[PROJECT_ID]/occurrences/[OCCURRENCE_ID]".
+		parts := strings.Split(occ.Name, "/")
+		if len(parts) < 2 {
+			continue
+		}
+		if _, ok := counts[parts[1]]; ok {
+			counts[parts[1]]++
+			total++
+		}
+	}
+	return total, nil
 }
 
 // [END occurrences_for_note]
 
 // [START occurrences_for_image]
 
-// getOccurrencesForImage retrieves all the Occurrences associated with a specified image.
-// Here, all Occurrences are simply printed and counted.
-func getOccurrencesForImage(ctx context.Context, client *containeranalysis.GrafeasV1Beta1Client, imageURL, projectID string) (int, error) {
+// getOccurrencesForImage retrieves and returns all the Occurrences associated with a specified image.
+// Each Occurrence is also printed to w.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func getOccurrencesForImage(ctx context.Context, w io.Writer, client grafeasClient, imageURL, projectID string, timeout time.Duration) ([]*grafeaspb.Occurrence, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
 	req := &grafeaspb.ListOccurrencesRequest{
 		Parent: fmt.Sprintf("projects/%s", projectID),
 		Filter: fmt.Sprintf("resourceUrl=%q", imageURL),
 	}
 	it := client.ListOccurrences(ctx, req)
-	count := 0
+	var occs []*grafeaspb.Occurrence
 	for {
 		occ, err := it.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return -1, err
+			return nil, err
 		}
 		// Write custom code to process each Occurrence here.
-		fmt.Println(occ)
-		count = count + 1
+		fmt.Fprintln(w, occ)
+		occs = append(occs, occ)
 	}
-	return count, nil
+	return occs, nil
 }
 
 // [END occurrences_for_image]
 
+// [START resolve_image_digest]
+
+// registryRequestTimeout bounds the request resolveImageDigest sends to the registry.
+const registryRequestTimeout = 30 * time.Second
+
+// resolveImageDigest returns the digest form of a Container Registry image reference, such as
+// "https://gcr.io/my-project/my-image@sha256:0123...", by asking the registry which manifest a tag points to.
+// Occurrences are keyed by digest, so the result can be passed to getOccurrencesForImage.
+// imageURL may omit the "https://" scheme and the tag, which defaults to "latest". A reference that already
+// contains a digest is returned unchanged. ctx and registryRequestTimeout bound the registry request.
+func resolveImageDigest(ctx context.Context, imageURL string) (string, error) {
+	registry, repository, tag, err := parseImageReference(imageURL)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(tag, "sha256:") {
+		return fmt.Sprintf("https://%s/%s@%s", registry, repository, tag), nil
+	}
+
+	ts, err := google.DefaultTokenSource(ctx, "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return "", err
+	}
+	token, err := ts.Token()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodHead, fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, repository, tag), nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth("oauth2accesstoken", token.AccessToken)
+	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json, application/vnd.docker.distribution.manifest.list.v2+json, application/vnd.oci.image.manifest.v1+json")
+	client := &http.Client{Timeout: registryRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolving %s: registry returned %s", imageURL, resp.Status)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("resolving %s: registry returned no digest", imageURL)
+	}
+	return fmt.Sprintf("https://%s/%s@%s", registry, repository, digest), nil
+}
+
+// parseImageReference splits an image reference of the form "[https://]registry/repository[:tag|@digest]" into
+// its parts. A missing tag defaults to "latest".
+func parseImageReference(imageURL string) (registry, repository, tagOrDigest string, err error) {
+	ref := strings.TrimPrefix(imageURL, "https://")
+	slash := strings.Index(ref, "/")
+	if slash <= 0 || slash == len(ref)-1 {
+		return "", "", "", fmt.Errorf("malformed image reference %q: want [https://]registry/repository[:tag]", imageURL)
+	}
+	registry, repository = ref[:slash], ref[slash+1:]
+	if at := strings.Index(repository, "@"); at >= 0 {
+		repository, tagOrDigest = repository[:at], repository[at+1:]
+		if !strings.HasPrefix(tagOrDigest, "sha256:") {
+			return "", "", "", fmt.Errorf("malformed image reference %q: digest must start with sha256:", imageURL)
+		}
+	} else if colon := strings.LastIndex(repository, ":"); colon >= 0 && !strings.Contains(repository[colon:], "/") {
+		repository, tagOrDigest = repository[:colon], repository[colon+1:]
+	} else {
+		tagOrDigest = "latest"
+	}
+	if repository == "" || tagOrDigest == "" {
+		return "", "", "", fmt.Errorf("malformed image reference %q: want [https://]registry/repository[:tag]", imageURL)
+	}
+	return registry, repository, tagOrDigest, nil
+}
+
+// [END resolve_image_digest]
+
+// [START stream_occurrences_for_image]
+
+// streamOccurrencesForImage calls fn for each Occurrence associated with a specified image, without holding
+// them all in memory. It stops and returns the error if fn returns an error or ctx is cancelled.
+// timeout bounds the whole stream, including the time spent in fn; if it is 0 or less, only ctx bounds it.
+func streamOccurrencesForImage(ctx context.Context, client grafeasClient, imageURL, projectID string, timeout time.Duration, fn func(*grafeaspb.Occurrence) error) error {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf("resourceUrl=%q", imageURL),
+	}
+	it := client.ListOccurrences(ctx, req)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		occ, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(occ); err != nil {
+			return err
+		}
+	}
+}
+
+// [END stream_occurrences_for_image]
+
+// [START occurrences_for_image_since]
+
+// getOccurrencesForImageSince retrieves and returns the Occurrences associated with a specified image that were
+// created after since, such as the time of the previous scan. A zero since returns every Occurrence, and a since in
+// the future returns none without calling the API.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func getOccurrencesForImageSince(ctx context.Context, client grafeasClient, imageURL, projectID string, since time.Time, timeout time.Duration) ([]*grafeaspb.Occurrence, error) {
+	if since.After(time.Now()) {
+		return nil, nil
+	}
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	filter := fmt.Sprintf("resourceUrl=%q", imageURL)
+	if !since.IsZero() {
+		filter += fmt.Sprintf(" AND createTime>%q", since.UTC().Format(time.RFC3339Nano))
+	}
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: filter,
+	}
+	it := client.ListOccurrences(ctx, req)
+	var occs []*grafeaspb.Occurrence
+	for {
+		occ, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		occs = append(occs, occ)
+	}
+	return occs, nil
+}
+
+// [END occurrences_for_image_since]
+
+// [START occurrences_for_image_prefix]
+
+// getOccurrencesForImagePrefix retrieves and returns all the Occurrences whose resource URL starts with urlPrefix,
+// for example every image in a repository. The "https://" scheme used in resource URLs may be omitted from
+// urlPrefix. The filter syntax only supports exact resource URL matches, so the Occurrences are filtered client-side.
+// This lists every Occurrence in the project, so its cost and latency grow with the size of the project, not with
+// the number of matches. When the full image URLs are known, call getOccurrencesForImage for each of them instead.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func getOccurrencesForImagePrefix(ctx context.Context, client grafeasClient, urlPrefix, projectID string, timeout time.Duration) ([]*grafeaspb.Occurrence, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	urlPrefix = strings.TrimPrefix(urlPrefix, "https://")
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+	}
+	it := client.ListOccurrences(ctx, req)
+	var occs []*grafeaspb.Occurrence
+	for {
+		occ, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(strings.TrimPrefix(occ.GetResource().GetUri(), "https://"), urlPrefix) {
+			occs = append(occs, occ)
+		}
+	}
+	return occs, nil
+}
+
+// [END occurrences_for_image_prefix]
+
+// [START occurrences_by_kind]
+
+// listOccurrencesByKind retrieves and returns every Occurrence of a specified kind in a project, across all images.
+// kind is the name of a Note kind, such as "DISCOVERY", "VULNERABILITY", "BUILD" or "ATTESTATION".
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func listOccurrencesByKind(ctx context.Context, client grafeasClient, projectID, kind string, timeout time.Duration) ([]*grafeaspb.Occurrence, error) {
+	if v, ok := common.NoteKind_value[kind]; !ok || common.NoteKind(v) == common.NoteKind_NOTE_KIND_UNSPECIFIED {
+		return nil, fmt.Errorf("unknown occurrence kind %q", kind)
+	}
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf("kind=%q", kind),
+	}
+	it := client.ListOccurrences(ctx, req)
+	var occs []*grafeaspb.Occurrence
+	for {
+		occ, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		occs = append(occs, occ)
+	}
+	return occs, nil
+}
+
+// [END occurrences_by_kind]
+
+// [START high_vulnerabilities_for_image]
+
+// getHighSeverityOccurrencesForImage retrieves the vulnerability Occurrences associated with a specified image
+// and returns those whose severity is minSeverity or higher.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func getHighSeverityOccurrencesForImage(ctx context.Context, client grafeasClient, imageURL, projectID string, minSeverity vulnerability.Severity, timeout time.Duration) ([]*grafeaspb.Occurrence, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf(`kind="VULNERABILITY" AND resourceUrl=%q`, imageURL),
+	}
+	it := client.ListOccurrences(ctx, req)
+	var occs []*grafeaspb.Occurrence
+	for {
+		occ, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		details := occ.GetVulnerability()
+		if details == nil {
+			// Not a vulnerability Occurrence.
+			continue
+		}
+		if details.Severity >= minSeverity {
+			occs = append(occs, occ)
+		}
+	}
+	return occs, nil
+}
+
+// [END high_vulnerabilities_for_image]
+
+// [START vulnerability_severity_gate]
+
+// failIfVulnerabilitiesAboveThreshold waits for the scan of a specified image to finish and returns an error
+// listing the IDs of the vulnerabilities, usually CVEs, whose severity is threshold or higher. It returns nil if
+// there are none, so a build step can use it to block deploying a vulnerable image.
+// discoveryTimeout bounds how long it waits for the scan, for example 10 minutes; a CI job may pass less.
+func failIfVulnerabilitiesAboveThreshold(ctx context.Context, client grafeasClient, projectID, imageURL string, threshold vulnerability.Severity, discoveryTimeout time.Duration) error {
+	discoveryOccurrence, err := pollDiscoveryOccurrenceFinished(ctx, client, imageURL, projectID, discoveryTimeout)
+	if err != nil {
+		return err
+	}
+	if status := discoveryOccurrence.GetDiscovered().GetDiscovered().GetAnalysisStatus(); status != discovery.Discovered_FINISHED_SUCCESS {
+		return fmt.Errorf("scan of %s did not succeed: %v", imageURL, status)
+	}
+
+	occs, err := getHighSeverityOccurrencesForImage(ctx, client, imageURL, projectID, threshold, 0)
+	if err != nil {
+		return err
+	}
+	if len(occs) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var ids []string
+	for _, occ := range occs {
+		// NoteName has the format "projects/[PROVIDER_ID]/notes/[NOTE_ID]".
+		id := path.Base(occ.NoteName)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return fmt.Errorf("%s has %d vulnerabilities with severity %v or higher: %s", imageURL, len(ids), threshold, strings.Join(ids, ", "))
+}
+
+// [END vulnerability_severity_gate]
+
+// [START fixable_vulnerabilities]
+
+// listFixableVulnerabilities retrieves the vulnerability Occurrences associated with a specified image and returns
+// those that can be fixed by upgrading at least one affected package. A package issue whose fixed version has kind
+// MAXIMUM has no fix yet.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func listFixableVulnerabilities(ctx context.Context, client grafeasClient, projectID, imageURL string, timeout time.Duration) ([]*grafeaspb.Occurrence, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf(`kind="VULNERABILITY" AND resourceUrl=%q`, imageURL),
+	}
+	it := client.ListOccurrences(ctx, req)
+	var occs []*grafeaspb.Occurrence
+	for {
+		occ, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range occ.GetVulnerability().GetPackageIssue() {
+			fixed := issue.GetFixedLocation()
+			if fixed != nil && fixed.GetVersion().GetKind() != pkg.Version_MAXIMUM {
+				occs = append(occs, occ)
+				break
+			}
+		}
+	}
+	return occs, nil
+}
+
+// [END fixable_vulnerabilities]
+
+// [START summarize_vulnerabilities]
+
+// summarizeVulnerabilityOccurrences counts the vulnerability Occurrences associated with a specified image
+// by severity. An image without vulnerabilities yields an empty map.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func summarizeVulnerabilityOccurrences(ctx context.Context, client grafeasClient, projectID, imageURL string, timeout time.Duration) (map[vulnerability.Severity]int, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf(`kind="VULNERABILITY" AND resourceUrl=%q`, imageURL),
+	}
+	it := client.ListOccurrences(ctx, req)
+	counts := make(map[vulnerability.Severity]int)
+	for {
+		occ, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if details := occ.GetVulnerability(); details != nil {
+			counts[details.Severity]++
+		}
+	}
+	return counts, nil
+}
+
+// [END summarize_vulnerabilities]
+
+// [START vulnerability_occurrences_summary]
+
+// getVulnerabilityOccurrencesSummary retrieves per-severity counts of the vulnerability Occurrences associated with a
+// specified image, computed by the server. This is cheaper than listing every Occurrence for images with many
+// vulnerabilities. The counts are also printed to w.
+func getVulnerabilityOccurrencesSummary(ctx context.Context, w io.Writer, client grafeasClient, projectID, imageURL string) (*grafeaspb.VulnerabilityOccurrencesSummary, error) {
+	req := &grafeaspb.GetVulnerabilityOccurrencesSummaryRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf("resourceUrl=%q", imageURL),
+	}
+	summary, err := client.GetVulnerabilityOccurrencesSummary(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range summary.GetCounts() {
+		fmt.Fprintf(w, "%s: %d total, %d fixable\n", c.Severity, c.TotalCount, c.FixableCount)
+	}
+	return summary, nil
+}
+
+// [END vulnerability_occurrences_summary]
+
+// [START vulnerability_cvss_scores]
+
+// vulnerabilityScore is the CVSS score of a single vulnerability found in an image.
+type vulnerabilityScore struct {
+	// Name is the ID of the vulnerability Note, usually a CVE such as "CVE-2019-1234".
+	Name     string
+	CVSS     float32
+	Severity vulnerability.Severity
+}
+
+// getVulnerabilityCVSSScores retrieves the vulnerability Occurrences associated with a specified image
+// and returns their CVSS scores. Occurrences without a CVSS score are skipped.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func getVulnerabilityCVSSScores(ctx context.Context, client grafeasClient, projectID, imageURL string, timeout time.Duration) ([]vulnerabilityScore, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf(`kind="VULNERABILITY" AND resourceUrl=%q`, imageURL),
+	}
+	it := client.ListOccurrences(ctx, req)
+	var scores []vulnerabilityScore
+	for {
+		occ, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		details := occ.GetVulnerability()
+		// A score of 0 means the score was not provided.
+		if details == nil || details.CvssScore == 0 {
+			continue
+		}
+		scores = append(scores, vulnerabilityScore{
+			// NoteName has the format "projects/[PROVIDER_ID]/notes/[NOTE_ID]".
+			Name:     path.Base(occ.NoteName),
+			CVSS:     details.CvssScore,
+			Severity: details.Severity,
+		})
+	}
+	return scores, nil
+}
+
+// [END vulnerability_cvss_scores]
+
+// [START export_sbom]
+
+// sbomPackage is a package found in an image, in either a PACKAGE or a VULNERABILITY Occurrence.
+type sbomPackage struct {
+	name, version, cpeURI string
+}
+
+// sbomVulnerability is a vulnerability affecting one of the packages in an image.
+type sbomVulnerability struct {
+	id       string
+	cvss     float32
+	severity vulnerability.Severity
+	affects  sbomPackage
+}
+
+// exportSBOM builds a software bill of materials for an image from its PACKAGE and VULNERABILITY Occurrences,
+// writes it to w and returns it. format must be "cyclonedx" (CycloneDX 1.4 JSON) or "spdx" (SPDX 2.3 JSON).
+// SPDX has no vulnerability section, so vulnerabilities are recorded as security references on the affected
+// packages.
+// timeout bounds the time spent fetching all pages; if it is 0 or less, only ctx bounds it.
+func exportSBOM(ctx context.Context, w io.Writer, client grafeasClient, projectID, imageURL, format string, timeout time.Duration) ([]byte, error) {
+	if format != "cyclonedx" && format != "spdx" {
+		return nil, fmt.Errorf("unsupported SBOM format %q: want \"cyclonedx\" or \"spdx\"", format)
+	}
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+
+	req := &grafeaspb.ListOccurrencesRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf("resourceUrl=%q", imageURL),
+	}
+	// Occurrences of other kinds are skipped below.
+	it := client.ListOccurrences(ctx, req)
+	var packages []sbomPackage
+	seen := make(map[sbomPackage]bool)
+	addPackage := func(p sbomPackage) {
+		if !seen[p] {
+			seen[p] = true
+			packages = append(packages, p)
+		}
+	}
+	var vulns []sbomVulnerability
+	for {
+		occ, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if installation := occ.GetInstallation().GetInstallation(); installation != nil {
+			for _, loc := range installation.Location {
+				addPackage(sbomPackage{name: installation.Name, version: formatPackageVersion(loc.Version), cpeURI: loc.CpeUri})
+			}
+		}
+		if details := occ.GetVulnerability(); details != nil {
+			for _, issue := range details.PackageIssue {
+				affected := issue.GetAffectedLocation()
+				p := sbomPackage{name: affected.GetPackage(), version: formatPackageVersion(affected.GetVersion()), cpeURI: affected.GetCpeUri()}
+				addPackage(p)
+				vulns = append(vulns, sbomVulnerability{
+					// NoteName has the format "projects/[PROVIDER_ID]/notes/[NOTE_ID]".
+					id:       path.Base(occ.NoteName),
+					cvss:     details.CvssScore,
+					severity: details.Severity,
+					affects:  p,
+				})
+			}
+		}
+	}
+
+	var sbom interface{}
+	if format == "cyclonedx" {
+		sbom = cycloneDXBOM(imageURL, packages, vulns)
+	} else {
+		sbom = spdxDocument(imageURL, packages, vulns)
+	}
+	b, err := json.MarshalIndent(sbom, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// formatPackageVersion formats v as [EPOCH:]NAME[-REVISION].
+func formatPackageVersion(v *pkg.Version) string {
+	if v == nil {
+		return ""
+	}
+	version := v.Name
+	if v.Epoch != 0 {
+		version = fmt.Sprintf("%d:%s", v.Epoch, version)
+	}
+	if v.Revision != "" {
+		version = version + "-" + v.Revision
+	}
+	return version
+}
+
+// cycloneDXSeverities maps vulnerability severities to CycloneDX 1.4 severities. Grafeas has no equivalent of
+// the CycloneDX "none" severity.
+var cycloneDXSeverities = map[vulnerability.Severity]string{
+	vulnerability.Severity_CRITICAL:             "critical",
+	vulnerability.Severity_HIGH:                 "high",
+	vulnerability.Severity_MEDIUM:               "medium",
+	vulnerability.Severity_LOW:                  "low",
+	vulnerability.Severity_MINIMAL:              "info",
+	vulnerability.Severity_SEVERITY_UNSPECIFIED: "unknown",
+}
+
+// cycloneDXRef returns the bom-ref of p. It includes the CPE URI, so packages that exportSBOM keeps apart get
+// different refs.
+func cycloneDXRef(p sbomPackage) string {
+	ref := p.name + "@" + p.version
+	if p.cpeURI != "" {
+		ref += "?cpe=" + p.cpeURI
+	}
+	return ref
+}
+
+// cycloneDXBOM returns a CycloneDX 1.4 BOM, ready to be marshaled to JSON.
+func cycloneDXBOM(imageURL string, packages []sbomPackage, vulns []sbomVulnerability) map[string]interface{} {
+	components := []map[string]interface{}{}
+	for _, p := range packages {
+		c := map[string]interface{}{
+			"type":    "library",
+			"bom-ref": cycloneDXRef(p),
+			"name":    p.name,
+			"version": p.version,
+		}
+		if p.cpeURI != "" {
+			c["cpe"] = p.cpeURI
+		}
+		components = append(components, c)
+	}
+	vulnerabilities := []map[string]interface{}{}
+	for _, v := range vulns {
+		severity, ok := cycloneDXSeverities[v.severity]
+		if !ok {
+			severity = "unknown"
+		}
+		rating := map[string]interface{}{
+			"severity": severity,
+		}
+		if v.cvss != 0 {
+			rating["score"] = v.cvss
+		}
+		vulnerabilities = append(vulnerabilities, map[string]interface{}{
+			"id":      v.id,
+			"ratings": []interface{}{rating},
+			"affects": []interface{}{map[string]string{"ref": cycloneDXRef(v.affects)}},
+		})
+	}
+	return map[string]interface{}{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.4",
+		"version":     1,
+		"metadata": map[string]interface{}{
+			"component": map[string]string{"type": "container", "name": imageURL},
+		},
+		"components":      components,
+		"vulnerabilities": vulnerabilities,
+	}
+}
+
+// spdxDocument returns an SPDX 2.3 document, ready to be marshaled to JSON. Licensing and copyright aren't
+// recorded in Occurrences, so they are NOASSERTION. Only CVE vulnerabilities are recorded, as links to their NVD
+// advisories.
+func spdxDocument(imageURL string, packages []sbomPackage, vulns []sbomVulnerability) map[string]interface{} {
+	spdxPackages := []map[string]interface{}{}
+	described := []string{}
+	for i, p := range packages {
+		var refs []map[string]string
+		if p.cpeURI != "" {
+			cpeType := "cpe22Type"
+			if strings.HasPrefix(p.cpeURI, "cpe:2.3:") {
+				cpeType = "cpe23Type"
+			}
+			refs = append(refs, map[string]string{
+				"referenceCategory": "SECURITY",
+				"referenceType":     cpeType,
+				"referenceLocator":  p.cpeURI,
+			})
+		}
+		for _, v := range vulns {
+			if v.affects == p && strings.HasPrefix(v.id, "CVE-") {
+				refs = append(refs, map[string]string{
+					"referenceCategory": "SECURITY",
+					"referenceType":     "advisory",
+					"referenceLocator":  "https://nvd.nist.gov/vuln/detail/" + v.id,
+				})
+			}
+		}
+		id := fmt.Sprintf("SPDXRef-Package-%d", i)
+		spdxPackage := map[string]interface{}{
+			"SPDXID":           id,
+			"name":             p.name,
+			"versionInfo":      p.version,
+			"downloadLocation": "NOASSERTION",
+			"filesAnalyzed":    false,
+			"licenseConcluded": "NOASSERTION",
+			"licenseDeclared":  "NOASSERTION",
+			"copyrightText":    "NOASSERTION",
+		}
+		if len(refs) > 0 {
+			spdxPackage["externalRefs"] = refs
+		}
+		spdxPackages = append(spdxPackages, spdxPackage)
+		described = append(described, id)
+	}
+	return map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              imageURL,
+		"documentNamespace": fmt.Sprintf("https://spdx.org/spdxdocs/%s-%d", strings.TrimPrefix(imageURL, "https://"), time.Now().Unix()),
+		"creationInfo": map[string]interface{}{
+			"created":  time.Now().UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: container-analysis-sample"},
+		},
+		"documentDescribes": described,
+		"packages":          spdxPackages,
+	}
+}
+
+// [END export_sbom]
+
+// [START diff_occurrences]
+
+// vulnerabilityKey identifies a vulnerability Occurrence across scans of the same image: the vulnerability Note plus
+// the packages it affects. Occurrence names can't be used because each scan creates new Occurrences. It returns ""
+// for Occurrences that aren't vulnerabilities or that lack a Note name.
+func vulnerabilityKey(occ *grafeaspb.Occurrence) string {
+	details := occ.GetVulnerability()
+	if details == nil || occ.GetNoteName() == "" {
+		return ""
+	}
+	var packages []string
+	for _, issue := range details.PackageIssue {
+		affected := issue.GetAffectedLocation()
+		packages = append(packages, affected.GetCpeUri()+":"+affected.GetPackage())
+	}
+	sort.Strings(packages)
+	return occ.GetNoteName() + "|" + strings.Join(packages, ",")
+}
+
+// diffOccurrences compares the vulnerability Occurrences from two scans of the same image and returns the ones that
+// are new in newOccs and the ones from oldOccs that are resolved. Duplicates of the same vulnerability are reported
+// once. Occurrences without a stable key can't be matched across scans, so those in newOccs are always reported as
+// added and those in oldOccs are ignored.
+func diffOccurrences(oldOccs, newOccs []*grafeaspb.Occurrence) (added, removed []*grafeaspb.Occurrence) {
+	oldKeys := make(map[string]bool)
+	for _, occ := range oldOccs {
+		if key := vulnerabilityKey(occ); key != "" {
+			oldKeys[key] = true
+		}
+	}
+	newKeys := make(map[string]bool)
+	for _, occ := range newOccs {
+		key := vulnerabilityKey(occ)
+		if key == "" {
+			if occ.GetVulnerability() != nil {
+				added = append(added, occ)
+			}
+			continue
+		}
+		if newKeys[key] {
+			continue
+		}
+		newKeys[key] = true
+		if !oldKeys[key] {
+			added = append(added, occ)
+		}
+	}
+	for _, occ := range oldOccs {
+		key := vulnerabilityKey(occ)
+		if key == "" || newKeys[key] {
+			continue
+		}
+		// Mark the key as seen so duplicates are reported once.
+		newKeys[key] = true
+		removed = append(removed, occ)
+	}
+	return added, removed
+}
+
+// [END diff_occurrences]
+
+// [START occurrence_table]
+
+// writeOccurrenceTable writes occs to w as a table with one row per Occurrence, showing its resource, kind,
+// severity and Note. Occurrences other than vulnerabilities have no severity and show "-".
+func writeOccurrenceTable(w io.Writer, occs []*grafeaspb.Occurrence) error {
+	if len(occs) == 0 {
+		_, err := fmt.Fprintln(w, "No occurrences found.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESOURCE\tKIND\tSEVERITY\tNOTE")
+	for _, occ := range occs {
+		severity := "-"
+		if details := occ.GetVulnerability(); details != nil {
+			severity = details.Severity.String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", occ.GetResource().GetUri(), occ.GetKind(), severity, occ.GetNoteName())
+	}
+	return tw.Flush()
+}
+
+// [END occurrence_table]
+
+// [START occurrences_json]
+
+// writeOccurrencesJSON writes occs to w as an indented JSON array in the standard protobuf JSON mapping, so that
+// other tools can parse it. Enums are written as their names and timestamps in RFC 3339 format.
+func writeOccurrencesJSON(w io.Writer, occs []*grafeaspb.Occurrence) error {
+	m := &jsonpb.Marshaler{}
+	msgs := make([]json.RawMessage, 0, len(occs))
+	for _, occ := range occs {
+		b := new(bytes.Buffer)
+		if err := m.Marshal(b, occ); err != nil {
+			return fmt.Errorf("marshaling %s: %v", occ.GetName(), err)
+		}
+		msgs = append(msgs, b.Bytes())
+	}
+	b, err := json.MarshalIndent(msgs, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+// [END occurrences_json]
+
 // [START pubsub]
 
+// occurrenceReceiveOptions bounds the memory and concurrency used by occurrencePubsub.
+// A zero value keeps the Pub/Sub client's defaults, which suit a dedicated worker. A small worker draining a
+// backlog of Occurrence notifications might instead use maxOutstandingMessages: 10 and numGoroutines: 1.
+type occurrenceReceiveOptions struct {
+	// maxOutstandingMessages is the maximum number of messages that are received but not yet acked or nacked.
+	maxOutstandingMessages int
+	// numGoroutines is the number of goroutines pulling messages from the subscription.
+	numGoroutines int
+	// errorOnNoMessages makes occurrencePubsub return errNoOccurrenceMessages if the timeout elapses
+	// without receiving any messages.
+	errorOnNoMessages bool
+}
+
+// errNoOccurrenceMessages is returned by occurrencePubsub when errorOnNoMessages is set and no messages arrive.
+var errNoOccurrenceMessages = errors.New("no Occurrence messages received")
+
+// apply sets the non-zero options on sub. It must be called before sub.Receive.
+func (o occurrenceReceiveOptions) apply(sub *pubsub.Subscription) {
+	if o.maxOutstandingMessages > 0 {
+		sub.ReceiveSettings.MaxOutstandingMessages = o.maxOutstandingMessages
+	}
+	if o.numGoroutines > 0 {
+		sub.ReceiveSettings.NumGoroutines = o.numGoroutines
+	}
+}
+
 // occurrencePubsub handles incoming Occurrences using a Cloud Pub/Sub subscription.
-func occurrencePubsub(ctx context.Context, subscriptionID string, timeout int, projectID string) (int, error) {
+// Each message received is printed to w and passed to handler. Messages for which handler returns an error
+// are nacked so that Pub/Sub redelivers them; all other messages are acked.
+// The number of messages processed successfully and the number of failures are returned separately.
+// Messages are received for timeout. opts limits how many messages are handled at once, and can make receiving no
+// messages at all an error.
+func occurrencePubsub(ctx context.Context, w io.Writer, subscriptionID string, timeout time.Duration, projectID string, handler func(context.Context, *pubsub.Message) error, opts occurrenceReceiveOptions) (processed, failed int, err error) {
 	var mu sync.Mutex
 	client, err := pubsub.NewClient(ctx, projectID)
 	if err != nil {
-		return -1, err
+		return -1, -1, err
 	}
 	// Subscribe to the requested Pub/Sub channel.
 	sub := client.Subscription(subscriptionID)
-	count := 0
+	opts.apply(sub)
 
-	// Listen to messages for 'timeout' seconds.
-	toctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	// Listen to messages for timeout.
+	toctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 	err = sub.Receive(toctx, func(ctx context.Context, msg *pubsub.Message) {
+		// Write custom code to process each Occurrence in handler.
+		herr := handler(ctx, msg)
 		mu.Lock()
-		count = count + 1
-		fmt.Printf("Message %d: %q\n", count, string(msg.Data))
+		defer mu.Unlock()
+		if herr != nil {
+			failed = failed + 1
+			fmt.Fprintf(w, "Message %s failed: %v\n", msg.ID, herr)
+			msg.Nack()
+			return
+		}
+		processed = processed + 1
+		fmt.Fprintf(w, "Message %d: %q\n", processed, string(msg.Data))
 		msg.Ack()
-		mu.Unlock()
 	})
 	if err != nil {
-		return -1, err
+		return -1, -1, err
+	}
+	// Print and return the number of Pub/Sub messages processed.
+	fmt.Fprintf(w, "Processed: %d, failed: %d\n", processed, failed)
+	if opts.errorOnNoMessages && processed+failed == 0 {
+		return 0, 0, errNoOccurrenceMessages
 	}
-	// Print and return the number of Pub/Sub messages received.
-	fmt.Println(count)
-	return count, nil
+	return processed, failed, nil
 }
 
 // createOccurrenceSubscription creates and returns a Pub/Sub subscription object listening to the Occurrence topic.
-func createOccurrenceSubscription(ctx context.Context, subscriptionID, projectID string) error {
+// The resulting subscription config is printed to w.
+// If enableMessageOrdering is set, messages published with the same ordering key are delivered in the order they
+// were published. Ordering only applies to messages whose publisher sets an ordering key, such as the image URL.
+// If deadLetterPolicy is not nil, messages that fail deadLetterPolicy.MaxDeliveryAttempts times are forwarded to
+// deadLetterPolicy.DeadLetterTopic, which has the form "projects/[PROJECT_ID]/topics/[TOPIC_ID]" and must exist.
+// If the subscription already exists, its dead-letter policy is updated to deadLetterPolicy if it differs. Message
+// ordering can't be changed once a subscription is created, so an error is returned if it differs.
+func createOccurrenceSubscription(ctx context.Context, w io.Writer, subscriptionID, projectID string, enableMessageOrdering bool, deadLetterPolicy *pubsub.DeadLetterPolicy) (*pubsub.Subscription, error) {
+	client, err := pubsub.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadLetterPolicy != nil {
+		parts := strings.Split(deadLetterPolicy.DeadLetterTopic, "/")
+		if len(parts) != 4 || parts[0] != "projects" || parts[2] != "topics" {
+			return nil, fmt.Errorf("invalid dead-letter topic %q: want projects/[PROJECT_ID]/topics/[TOPIC_ID]", deadLetterPolicy.DeadLetterTopic)
+		}
+		ok, err := client.TopicInProject(parts[3], parts[1]).Exists(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("dead-letter topic %q does not exist", deadLetterPolicy.DeadLetterTopic)
+		}
+	}
+
+	sub := client.Subscription(subscriptionID)
+	exists, err := sub.Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		// This topic id will automatically receive messages when Occurrences are added or modified
+		topicID := "container-analysis-occurrences-v1beta1"
+		topic := client.Topic(topicID)
+		config := pubsub.SubscriptionConfig{
+			Topic:                 topic,
+			EnableMessageOrdering: enableMessageOrdering,
+			DeadLetterPolicy:      deadLetterPolicy,
+		}
+		if sub, err = client.CreateSubscription(ctx, subscriptionID, config); err != nil {
+			return nil, err
+		}
+	} else {
+		config, err := sub.Config(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if config.EnableMessageOrdering != enableMessageOrdering {
+			return nil, fmt.Errorf("subscription %s exists with message ordering %t; want %t. Delete it to change message ordering", subscriptionID, config.EnableMessageOrdering, enableMessageOrdering)
+		}
+		current := config.DeadLetterPolicy
+		if (current == nil) != (deadLetterPolicy == nil) ||
+			(current != nil && (current.DeadLetterTopic != deadLetterPolicy.DeadLetterTopic || current.MaxDeliveryAttempts != deadLetterPolicy.MaxDeliveryAttempts)) {
+			// A zero DeadLetterPolicy removes the existing policy.
+			update := pubsub.SubscriptionConfigToUpdate{DeadLetterPolicy: &pubsub.DeadLetterPolicy{}}
+			if deadLetterPolicy != nil {
+				update.DeadLetterPolicy = deadLetterPolicy
+			}
+			if _, err := sub.Update(ctx, update); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	config, err := sub.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(w, "Subscription %s: topic: %s, message ordering: %t\n", sub.ID(), config.Topic.ID(), config.EnableMessageOrdering)
+	if config.DeadLetterPolicy != nil {
+		fmt.Fprintf(w, "Dead-letter topic: %s, max delivery attempts: %d\n", config.DeadLetterPolicy.DeadLetterTopic, config.DeadLetterPolicy.MaxDeliveryAttempts)
+	}
+	return sub, nil
+}
+
+// deleteOccurrenceSubscription deletes a Pub/Sub subscription created by createOccurrenceSubscription.
+// A subscription that doesn't exist is treated as already deleted.
+func deleteOccurrenceSubscription(ctx context.Context, subscriptionID, projectID string) error {
 	client, err := pubsub.NewClient(ctx, projectID)
 	if err != nil {
 		return err
 	}
 
-	// This topic id will automatically receive messages when Occurrences are added or modified
-	topicID := "container-analysis-occurrences-v1beta1"
-	topic := client.Topic(topicID)
-	config := pubsub.SubscriptionConfig{Topic: topic}
-	_, err = client.CreateSubscription(ctx, subscriptionID, config)
-	return err
+	sub := client.Subscription(subscriptionID)
+	if err := sub.Delete(ctx); err != nil && status.Code(err) != codes.NotFound {
+		return err
+	}
+	return nil
 }
 
 // [END pubsub]
+
+// [START dispatch_occurrences]
+
+// webhookConcurrency is the maximum number of webhook requests dispatchOccurrences has in flight at once.
+const webhookConcurrency = 10
+
+// postOccurrenceToWebhook POSTs the Occurrence notification in msg to webhookURL as JSON. Any response other than
+// 2xx is an error.
+func postOccurrenceToWebhook(ctx context.Context, client *http.Client, webhookURL string, msg *pubsub.Message) error {
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(msg.Data))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned %s", webhookURL, resp.Status)
+	}
+	return nil
+}
+
+// dispatchOccurrences forwards Occurrence notifications received on a Pub/Sub subscription to an HTTP webhook,
+// such as a Slack or PagerDuty integration, for timeout. A message is acked only if the webhook accepts it with a
+// 2xx response; otherwise it is nacked so that Pub/Sub redelivers it. The number of messages delivered is returned.
+func dispatchOccurrences(ctx context.Context, subscriptionID, projectID, webhookURL string, timeout time.Duration) (int, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return 0, fmt.Errorf("invalid webhook URL %q: want an http or https URL", webhookURL)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("timeout must be positive, got %v", timeout)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	handler := func(ctx context.Context, msg *pubsub.Message) error {
+		return postOccurrenceToWebhook(ctx, client, webhookURL, msg)
+	}
+	opts := occurrenceReceiveOptions{
+		maxOutstandingMessages: webhookConcurrency,
+		numGoroutines:          1,
+	}
+	delivered, _, err := occurrencePubsub(ctx, ioutil.Discard, subscriptionID, timeout, projectID, handler, opts)
+	if err != nil {
+		return 0, err
+	}
+	return delivered, nil
+}
+
+// [END dispatch_occurrences]
@@ -15,28 +15,52 @@
 package sample
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	containeranalysis "cloud.google.com/go/containeranalysis/apiv1beta1"
 	pubsub "cloud.google.com/go/pubsub"
 	"github.com/GoogleCloudPlatform/golang-samples/internal/testutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	gax "github.com/googleapis/gax-go/v2"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/attestation"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/common"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/deployment"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/discovery"
 	grafeaspb "google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/grafeas"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/image"
+	pkg "google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/package"
+	"google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/provenance"
 	vulnerability "google.golang.org/genproto/googleapis/devtools/containeranalysis/v1beta1/vulnerability"
+	iampb "google.golang.org/genproto/googleapis/iam/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type TestVariables struct {
 	ctx       context.Context
-	client    *containeranalysis.GrafeasV1Beta1Client
+	client    *containerAnalysisClient
 	noteID    string
 	subID     string
 	imageUrl  string
 	projectID string
 	noteObj   *grafeaspb.Note
 	tryLimit  int
+	timeout   time.Duration
 }
 
 // Run before each test. Creates a set of useful variables
@@ -44,13 +68,18 @@ func setup(t *testing.T) TestVariables {
 	tc := testutil.SystemTest(t)
 	// Create client and context
 	ctx := context.Background()
-	client, _ := containeranalysis.NewGrafeasV1Beta1Client(ctx)
+	client, err := newContainerAnalysisClient(ctx, "")
+	if err != nil {
+		t.Fatalf("newContainerAnalysisClient: %v", err)
+	}
 	// Get current timestamp
 	timestamp := strconv.Itoa(int(time.Now().Unix()))
 	// Make a random portion so each test is unique
 	rand := strconv.Itoa(rand.Int())
 	// Set how many times to retry network tasks
 	tryLimit := 20
+	// Set how long to wait for each request
+	timeout := 30 * time.Second
 
 	// Create variables used by tests
 	projectID := tc.ProjectID
@@ -61,7 +90,7 @@ func setup(t *testing.T) TestVariables {
 	if err != nil {
 		t.Fatalf("createNote(%s): %v", noteID, err)
 	}
-	v := TestVariables{ctx, client, noteID, subID, imageUrl, projectID, noteObj, tryLimit}
+	v := TestVariables{ctx, client, noteID, subID, imageUrl, projectID, noteObj, tryLimit, timeout}
 	return v
 }
 
@@ -73,28 +102,246 @@ func teardown(t *testing.T, v TestVariables) {
 	}
 }
 
+// unimplementedGrafeasClient fails the test when any grafeasClient method is called. Fakes embed it and override
+// only the methods their test expects.
+type unimplementedGrafeasClient struct {
+	t *testing.T
+}
+
+func (u unimplementedGrafeasClient) CreateNote(ctx context.Context, req *grafeaspb.CreateNoteRequest, opts ...gax.CallOption) (*grafeaspb.Note, error) {
+	u.t.Fatalf("unexpected call to CreateNote")
+	return nil, nil
+}
+
+func (u unimplementedGrafeasClient) GetNote(ctx context.Context, req *grafeaspb.GetNoteRequest, opts ...gax.CallOption) (*grafeaspb.Note, error) {
+	u.t.Fatalf("unexpected call to GetNote")
+	return nil, nil
+}
+
+func (u unimplementedGrafeasClient) UpdateNote(ctx context.Context, req *grafeaspb.UpdateNoteRequest, opts ...gax.CallOption) (*grafeaspb.Note, error) {
+	u.t.Fatalf("unexpected call to UpdateNote")
+	return nil, nil
+}
+
+func (u unimplementedGrafeasClient) DeleteNote(ctx context.Context, req *grafeaspb.DeleteNoteRequest, opts ...gax.CallOption) error {
+	u.t.Fatalf("unexpected call to DeleteNote")
+	return nil
+}
+
+func (u unimplementedGrafeasClient) ListNotes(ctx context.Context, req *grafeaspb.ListNotesRequest, opts ...gax.CallOption) *containeranalysis.NoteIterator {
+	u.t.Fatalf("unexpected call to ListNotes")
+	return nil
+}
+
+func (u unimplementedGrafeasClient) ListNoteOccurrences(ctx context.Context, req *grafeaspb.ListNoteOccurrencesRequest, opts ...gax.CallOption) *containeranalysis.OccurrenceIterator {
+	u.t.Fatalf("unexpected call to ListNoteOccurrences")
+	return nil
+}
+
+func (u unimplementedGrafeasClient) CreateOccurrence(ctx context.Context, req *grafeaspb.CreateOccurrenceRequest, opts ...gax.CallOption) (*grafeaspb.Occurrence, error) {
+	u.t.Fatalf("unexpected call to CreateOccurrence")
+	return nil, nil
+}
+
+func (u unimplementedGrafeasClient) BatchCreateOccurrences(ctx context.Context, req *grafeaspb.BatchCreateOccurrencesRequest, opts ...gax.CallOption) (*grafeaspb.BatchCreateOccurrencesResponse, error) {
+	u.t.Fatalf("unexpected call to BatchCreateOccurrences")
+	return nil, nil
+}
+
+func (u unimplementedGrafeasClient) GetOccurrence(ctx context.Context, req *grafeaspb.GetOccurrenceRequest, opts ...gax.CallOption) (*grafeaspb.Occurrence, error) {
+	u.t.Fatalf("unexpected call to GetOccurrence")
+	return nil, nil
+}
+
+func (u unimplementedGrafeasClient) GetOccurrenceNote(ctx context.Context, req *grafeaspb.GetOccurrenceNoteRequest, opts ...gax.CallOption) (*grafeaspb.Note, error) {
+	u.t.Fatalf("unexpected call to GetOccurrenceNote")
+	return nil, nil
+}
+
+func (u unimplementedGrafeasClient) UpdateOccurrence(ctx context.Context, req *grafeaspb.UpdateOccurrenceRequest, opts ...gax.CallOption) (*grafeaspb.Occurrence, error) {
+	u.t.Fatalf("unexpected call to UpdateOccurrence")
+	return nil, nil
+}
+
+func (u unimplementedGrafeasClient) DeleteOccurrence(ctx context.Context, req *grafeaspb.DeleteOccurrenceRequest, opts ...gax.CallOption) error {
+	u.t.Fatalf("unexpected call to DeleteOccurrence")
+	return nil
+}
+
+func (u unimplementedGrafeasClient) ListOccurrences(ctx context.Context, req *grafeaspb.ListOccurrencesRequest, opts ...gax.CallOption) *containeranalysis.OccurrenceIterator {
+	u.t.Fatalf("unexpected call to ListOccurrences")
+	return nil
+}
+
+func (u unimplementedGrafeasClient) GetVulnerabilityOccurrencesSummary(ctx context.Context, req *grafeaspb.GetVulnerabilityOccurrencesSummaryRequest, opts ...gax.CallOption) (*grafeaspb.VulnerabilityOccurrencesSummary, error) {
+	u.t.Fatalf("unexpected call to GetVulnerabilityOccurrencesSummary")
+	return nil, nil
+}
+
+// unimplementedIAMPolicyClient fails the test when any iamPolicyClient method is called. Fakes embed it and override
+// only the methods their test expects.
+type unimplementedIAMPolicyClient struct {
+	t *testing.T
+}
+
+func (u unimplementedIAMPolicyClient) GetIamPolicy(ctx context.Context, req *iampb.GetIamPolicyRequest, opts ...gax.CallOption) (*iampb.Policy, error) {
+	u.t.Fatalf("unexpected call to GetIamPolicy")
+	return nil, nil
+}
+
+func (u unimplementedIAMPolicyClient) SetIamPolicy(ctx context.Context, req *iampb.SetIamPolicyRequest, opts ...gax.CallOption) (*iampb.Policy, error) {
+	u.t.Fatalf("unexpected call to SetIamPolicy")
+	return nil, nil
+}
+
+func (u unimplementedIAMPolicyClient) TestIamPermissions(ctx context.Context, req *iampb.TestIamPermissionsRequest, opts ...gax.CallOption) (*iampb.TestIamPermissionsResponse, error) {
+	u.t.Fatalf("unexpected call to TestIamPermissions")
+	return nil, nil
+}
+
+// fakeGrafeasClient records the requests it receives and serves Occurrences from memory. Methods it doesn't
+// override fail the test.
+type fakeGrafeasClient struct {
+	unimplementedGrafeasClient
+	noteReqs    []*grafeaspb.CreateNoteRequest
+	updateReqs  []*grafeaspb.UpdateOccurrenceRequest
+	occurrences map[string]*grafeaspb.Occurrence
+}
+
+func (f *fakeGrafeasClient) CreateNote(ctx context.Context, req *grafeaspb.CreateNoteRequest, opts ...gax.CallOption) (*grafeaspb.Note, error) {
+	f.noteReqs = append(f.noteReqs, req)
+	note := proto.Clone(req.Note).(*grafeaspb.Note)
+	note.Name = fmt.Sprintf("%s/notes/%s", req.Parent, req.NoteId)
+	return note, nil
+}
+
+func (f *fakeGrafeasClient) GetOccurrence(ctx context.Context, req *grafeaspb.GetOccurrenceRequest, opts ...gax.CallOption) (*grafeaspb.Occurrence, error) {
+	occ, ok := f.occurrences[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "occurrence %s not found", req.Name)
+	}
+	return proto.Clone(occ).(*grafeaspb.Occurrence), nil
+}
+
+func (f *fakeGrafeasClient) GetOccurrenceNote(ctx context.Context, req *grafeaspb.GetOccurrenceNoteRequest, opts ...gax.CallOption) (*grafeaspb.Note, error) {
+	occ, ok := f.occurrences[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "occurrence %s not found", req.Name)
+	}
+	return &grafeaspb.Note{Name: occ.NoteName}, nil
+}
+
+func (f *fakeGrafeasClient) UpdateOccurrence(ctx context.Context, req *grafeaspb.UpdateOccurrenceRequest, opts ...gax.CallOption) (*grafeaspb.Occurrence, error) {
+	f.updateReqs = append(f.updateReqs, req)
+	f.occurrences[req.Name] = proto.Clone(req.Occurrence).(*grafeaspb.Occurrence)
+	return req.Occurrence, nil
+}
+
+func TestFakeGrafeasClient(t *testing.T) {
+	ctx := context.Background()
+	occurrenceName := "projects/my-project/occurrences/my-occurrence"
+	fake := &fakeGrafeasClient{unimplementedGrafeasClient: unimplementedGrafeasClient{t}, occurrences: map[string]*grafeaspb.Occurrence{
+		occurrenceName: {
+			Name:     occurrenceName,
+			NoteName: "projects/my-project/notes/my-scanner",
+			Details: &grafeaspb.Occurrence_Discovered{
+				Discovered: &discovery.Details{
+					Discovered: &discovery.Discovered{AnalysisStatus: discovery.Discovered_PENDING},
+				},
+			},
+		},
+	}}
+
+	if _, err := createBuildNote(ctx, fake, "my-builder", "my-project", "1.0"); err != nil {
+		t.Fatalf("createBuildNote: %v", err)
+	}
+	if len(fake.noteReqs) != 1 {
+		t.Fatalf("CreateNote called %d times; want: 1", len(fake.noteReqs))
+	}
+	req := fake.noteReqs[0]
+	if req.Parent != "projects/my-project" || req.NoteId != "my-builder" || req.Note.GetBuild().GetBuilderVersion() != "1.0" {
+		t.Errorf("createBuildNote sent %v; want a build Note my-builder in projects/my-project with version 1.0", req)
+	}
+
+	if _, err := updateDiscoveryOccurrenceStatus(ctx, fake, occurrenceName, discovery.Discovered_SCANNING); err != nil {
+		t.Fatalf("updateDiscoveryOccurrenceStatus: %v", err)
+	}
+	if len(fake.updateReqs) != 1 || fake.updateReqs[0].Name != occurrenceName {
+		t.Fatalf("UpdateOccurrence requests %v; want one for %s", fake.updateReqs, occurrenceName)
+	}
+	if got := fake.updateReqs[0].Occurrence.GetDiscovered().GetDiscovered().GetAnalysisStatus(); got != discovery.Discovered_SCANNING {
+		t.Errorf("updateDiscoveryOccurrenceStatus sent status %v; want: %v", got, discovery.Discovered_SCANNING)
+	}
+
+	note, err := getNoteForOccurrence(ctx, fake, occurrenceName)
+	if err != nil {
+		t.Fatalf("getNoteForOccurrence: %v", err)
+	}
+	if note.Name != "projects/my-project/notes/my-scanner" {
+		t.Errorf("getNoteForOccurrence returned %s; want: projects/my-project/notes/my-scanner", note.Name)
+	}
+	if _, err := getNoteForOccurrence(ctx, fake, "projects/my-project/occurrences/missing"); err == nil {
+		t.Error("expected error from getNoteForOccurrence for a missing occurrence; got nil")
+	}
+
+	// A zero timeout leaves the request bounded only by ctx.
+	if _, err := getOccurrence(ctx, ioutil.Discard, fake, occurrenceName, 0); err != nil {
+		t.Errorf("getOccurrence with a zero timeout: %v", err)
+	}
+}
+
 func TestCreateNote(t *testing.T) {
 	v := setup(t)
 
-	newNote, err := getNote(v.ctx, v.client, v.noteID, v.projectID)
+	buf := &bytes.Buffer{}
+	newNote, err := getNote(v.ctx, buf, v.client, v.noteID, v.projectID, v.timeout)
 	if err != nil {
 		t.Errorf("getNote(%s): %v", v.noteID, err)
 	} else if newNote == nil {
 		t.Error("created note is nil")
 	} else if newNote.Name != v.noteObj.Name {
 		t.Errorf("created note has wrong name: %s; want: %s", newNote.Name, v.noteObj.Name)
+	} else if got := buf.String(); !strings.Contains(got, v.noteObj.Name) {
+		t.Errorf("getNote output %q; want to contain: %s", got, v.noteObj.Name)
+	}
+
+	if _, err := createNoteWithType(v.ctx, v.client, "untyped-"+v.noteID, v.projectID, &grafeaspb.Note{}); err == nil {
+		t.Error("expected error from createNoteWithType with no note type; got nil")
 	}
 
 	teardown(t, v)
 }
 
+func TestListNotes(t *testing.T) {
+	v := setup(t)
+
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		notes, err := listNotes(v.ctx, v.client, v.projectID, "", 1, v.timeout)
+		if err != nil {
+			r.Errorf("listNotes(%s): %v", v.projectID, err)
+			return
+		}
+		found := false
+		for _, n := range notes {
+			if n.Name == v.noteObj.Name {
+				found = true
+			}
+		}
+		if !found {
+			r.Errorf("listNotes returned %d notes; want to contain: %s", len(notes), v.noteObj.Name)
+		}
+	})
+
+	teardown(t, v)
+}
+
 func TestDeleteNote(t *testing.T) {
 	v := setup(t)
 
 	if err := deleteNote(v.ctx, v.client, v.noteID, v.projectID); err != nil {
 		t.Errorf("deleteNote(%s): %v", v.noteID, err)
 	}
-	deleted, err := getNote(v.ctx, v.client, v.noteID, v.projectID)
+	deleted, err := getNote(v.ctx, ioutil.Discard, v.client, v.noteID, v.projectID, v.timeout)
 	if err == nil {
 		t.Error("expected error from getNote; got nil")
 	}
@@ -116,7 +363,7 @@ func TestUpdateNote(t *testing.T) {
 	} else if returned.ShortDescription != description {
 		t.Errorf("returned note doesn't contain requested description text: %s; want: %s", returned.ShortDescription, description)
 	}
-	updated, err := getNote(v.ctx, v.client, v.noteID, v.projectID)
+	updated, err := getNote(v.ctx, ioutil.Discard, v.client, v.noteID, v.projectID, v.timeout)
 	if err != nil {
 		t.Errorf("getNote(%s): %v", v.noteID, err)
 	} else if updated == nil {
@@ -137,7 +384,7 @@ func TestCreateOccurrence(t *testing.T) {
 	} else if created == nil {
 		t.Error("returned occurrence is nil")
 	} else {
-		retrieved, err := getOccurrence(v.ctx, v.client, created.Name)
+		retrieved, err := getOccurrence(v.ctx, ioutil.Discard, v.client, created.Name, v.timeout)
 		if err != nil {
 			t.Errorf("getOccurrence(%s): %v", created.Name, err)
 		} else if retrieved == nil {
@@ -150,144 +397,1505 @@ func TestCreateOccurrence(t *testing.T) {
 	teardown(t, v)
 }
 
-func TestDeleteOccurrence(t *testing.T) {
+// createAttestationNote creates an attestation authority Note for use in tests.
+func createAttestationNote(t *testing.T, v TestVariables) string {
+	t.Helper()
+	noteID := "attestation-" + v.noteID
+	note := &grafeaspb.Note{
+		Type: &grafeaspb.Note_AttestationAuthority{
+			AttestationAuthority: &attestation.Authority{
+				Hint: &attestation.Authority_Hint{HumanReadableName: "test-attestor"},
+			},
+		},
+	}
+	if _, err := createNoteWithType(v.ctx, v.client, noteID, v.projectID, note); err != nil {
+		t.Fatalf("createNoteWithType(%s): %v", noteID, err)
+	}
+	return noteID
+}
+
+func TestCreateAttestationOccurrence(t *testing.T) {
 	v := setup(t)
+	noteID := createAttestationNote(t, v)
 
-	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if _, err := createAttestationOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, nil, "key-id"); err == nil {
+		t.Error("expected error from createAttestationOccurrence with an empty signature; got nil")
+	}
+
+	signature := []byte("-----BEGIN PGP MESSAGE-----")
+	created, err := createAttestationOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, signature, "key-id")
 	if err != nil {
-		t.Errorf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
-	} else if created == nil {
-		t.Error("createOccurrence returns nil Occurrence object")
-	} else {
-		err = deleteOccurrence(v.ctx, v.client, created.Name)
-		if err != nil {
-			t.Errorf("deleteOccurrence(%s): %v", created.Name, err)
-		}
-		deleted, err := getOccurrence(v.ctx, v.client, created.Name)
-		if err == nil {
-			t.Error("getOccurrence returned nil error after DeleteOccurrence. expected error")
-		}
-		if deleted != nil {
-			t.Errorf("getOccurrence returned occurrence after deletion: %v; expected nil", deleted)
-		}
+		t.Errorf("createAttestationOccurrence(%s, %s): %v", v.imageUrl, noteID, err)
+	} else if pgp := created.GetAttestation().GetAttestation().GetPgpSignedAttestation(); pgp == nil {
+		t.Error("created occurrence has no PGP signed attestation")
+	} else if pgp.GetPgpKeyId() != "key-id" {
+		t.Errorf("created attestation has key id: %s; want: %s", pgp.GetPgpKeyId(), "key-id")
 	}
 
+	// Clean up
+	if created != nil {
+		deleteOccurrence(v.ctx, v.client, created.Name)
+	}
+	deleteNote(v.ctx, v.client, noteID, v.projectID)
 	teardown(t, v)
 }
 
-func TestUpdateOccurrence(t *testing.T) {
-	t.Skip("Flaky. golang-samples#785")
+// signPGP returns an ASCII-armored PGP signed message containing payload.
+func signPGP(t *testing.T, signer *openpgp.Entity, payload []byte) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	aw, err := armor.Encode(buf, "PGP MESSAGE", nil)
+	if err != nil {
+		t.Fatalf("armor.Encode: %v", err)
+	}
+	sw, err := openpgp.Sign(aw, signer, nil, nil)
+	if err != nil {
+		t.Fatalf("openpgp.Sign: %v", err)
+	}
+	sw.Write(payload)
+	sw.Close()
+	aw.Close()
+	return buf.Bytes()
+}
+
+// armoredPublicKey returns the ASCII-armored public key of e.
+func armoredPublicKey(t *testing.T, e *openpgp.Entity) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	aw, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("armor.Encode: %v", err)
+	}
+	if err := e.Serialize(aw); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	aw.Close()
+	return buf.Bytes()
+}
 
+func TestVerifyAttestationOccurrence(t *testing.T) {
 	v := setup(t)
+	noteID := createAttestationNote(t, v)
 
-	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	signer, err := openpgp.NewEntity("signer", "", "signer@example.com", nil)
 	if err != nil {
-		t.Errorf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
-	} else if created == nil {
-		t.Error("createOccurrence returns nil Occurrence object")
-	} else {
-		newType := "updated"
+		t.Fatalf("openpgp.NewEntity: %v", err)
+	}
+	other, err := openpgp.NewEntity("other", "", "other@example.com", nil)
+	if err != nil {
+		t.Fatalf("openpgp.NewEntity: %v", err)
+	}
 
-		details := vulnerability.Details{Type: newType}
-		vulDetails := grafeaspb.Occurrence_Vulnerability{Vulnerability: &details}
-		resource := grafeaspb.Resource{Uri: created.Resource.Uri}
-		occurrence := grafeaspb.Occurrence{NoteName: created.NoteName, Resource: &resource, Details: &vulDetails}
+	payload := []byte(`{"critical":{"image":{"docker-manifest-digest":"sha256:0000"}}}`)
+	keyID := fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint)
+	created, err := createAttestationOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, signPGP(t, signer, payload), keyID)
+	if err != nil {
+		t.Fatalf("createAttestationOccurrence(%s, %s): %v", v.imageUrl, noteID, err)
+	}
 
-		returned, err := updateOccurrence(v.ctx, v.client, &occurrence, created.Name)
-		if err != nil {
-			t.Errorf("updateOccurrence(%s): %v", created.Name, err)
-		} else if returned.GetVulnerability().Type != newType {
-			t.Errorf("returned occurrence doesn't contain requested vulnerability type: %s; want: %s", returned.GetVulnerability().Type, newType)
-		}
-		retrieved, err := getOccurrence(v.ctx, v.client, created.Name)
-		if err != nil {
-			t.Errorf("getOccurrence(%s): %v", created.Name, err)
-		} else if retrieved == nil {
-			t.Error("GetOccurrence returned nil Occurrence object")
-		} else if retrieved.GetVulnerability().Type != newType {
-			t.Errorf("updated occurrence doesn't contain requested vulnerability type: %s; want: %s", retrieved.GetVulnerability().Type, newType)
-		}
+	if ok, err := verifyAttestationOccurrence(v.ctx, v.client, created.Name, armoredPublicKey(t, signer)); !ok || err != nil {
+		t.Errorf("verifyAttestationOccurrence with the signing key = %v, %v; want: true, nil", ok, err)
+	}
+	if ok, err := verifyAttestationOccurrence(v.ctx, v.client, created.Name, armoredPublicKey(t, other)); ok || err == nil {
+		t.Errorf("verifyAttestationOccurrence with another key = %v, %v; want: false and an error", ok, err)
 	}
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	deleteNote(v.ctx, v.client, noteID, v.projectID)
 	teardown(t, v)
 }
 
-func TestOccurrencesForImage(t *testing.T) {
+func TestBuildOccurrence(t *testing.T) {
 	v := setup(t)
 
-	origCount, err := getOccurrencesForImage(v.ctx, v.client, v.imageUrl, v.projectID)
+	noteID := "build-" + v.noteID
+	note, err := createBuildNote(v.ctx, v.client, noteID, v.projectID, "1.0")
 	if err != nil {
-		t.Errorf("getOccurrenceForImage(%s): %v", v.imageUrl, err)
+		t.Fatalf("createBuildNote(%s): %v", noteID, err)
 	}
-	if origCount != 0 {
-		t.Errorf("unexpected initial number of occurrences: %d; want: %d", origCount, 0)
+	if got := note.GetBuild().GetBuilderVersion(); got != "1.0" {
+		t.Errorf("created note has builder version: %s; want: %s", got, "1.0")
 	}
-	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+
+	if _, err := createBuildOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, &provenance.BuildProvenance{Id: "build-1"}); err == nil {
+		t.Error("expected error from createBuildOccurrence without built artifacts; got nil")
+	}
+
+	prov := &provenance.BuildProvenance{
+		Id:               "build-1",
+		BuiltArtifacts:   []*provenance.Artifact{{Id: v.imageUrl}},
+		SourceProvenance: &provenance.Source{ArtifactStorageSourceUri: "gs://my-bucket/source.tgz"},
+	}
+	created, err := createBuildOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, prov)
 	if err != nil {
-		t.Errorf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
-	} else if created == nil {
-		t.Error("createOccurrence returns nil Occurrence object")
+		t.Errorf("createBuildOccurrence(%s, %s): %v", v.imageUrl, noteID, err)
+	} else if got := created.GetBuild().GetProvenance().GetId(); got != prov.Id {
+		t.Errorf("created occurrence has build id: %s; want: %s", got, prov.Id)
 	}
-	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
-		newCount, err := getOccurrencesForImage(v.ctx, v.client, v.imageUrl, v.projectID)
-		if err != nil {
-			r.Errorf("getOccurrencesForImage(%s): %v", v.imageUrl, err)
+
+	// Clean up
+	if created != nil {
+		deleteOccurrence(v.ctx, v.client, created.Name)
+	}
+	deleteNote(v.ctx, v.client, noteID, v.projectID)
+	teardown(t, v)
+}
+
+func TestDeploymentOccurrence(t *testing.T) {
+	v := setup(t)
+
+	noteID := "deployment-" + v.noteID
+	if _, err := createDeploymentNote(v.ctx, v.client, noteID, v.projectID, []string{v.imageUrl}); err != nil {
+		t.Fatalf("createDeploymentNote(%s): %v", noteID, err)
+	}
+
+	if _, err := createDeploymentOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, time.Time{}, deployment.Deployment_GKE); err == nil {
+		t.Error("expected error from createDeploymentOccurrence without a deploy time; got nil")
+	}
+
+	created, err := createDeploymentOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, time.Now(), deployment.Deployment_GKE)
+	if err != nil {
+		t.Errorf("createDeploymentOccurrence(%s, %s): %v", v.imageUrl, noteID, err)
+	} else if got := created.GetDeployment().GetDeployment().GetPlatform(); got != deployment.Deployment_GKE {
+		t.Errorf("created occurrence has platform: %v; want: %v", got, deployment.Deployment_GKE)
+	}
+
+	// Clean up
+	if created != nil {
+		deleteOccurrence(v.ctx, v.client, created.Name)
+	}
+	deleteNote(v.ctx, v.client, noteID, v.projectID)
+	teardown(t, v)
+}
+
+func TestImageOccurrence(t *testing.T) {
+	v := setup(t)
+
+	noteID := "image-" + v.noteID
+	baseFingerprint := &image.Fingerprint{V1Name: "sha256:base", V2Blob: []string{"sha256:layer0"}}
+	if _, err := createImageNote(v.ctx, v.client, noteID, v.projectID, "https://gcr.io/my-project/base", &image.Fingerprint{}); err == nil {
+		t.Error("expected error from createImageNote without a V1Name; got nil")
+	}
+	if _, err := createImageNote(v.ctx, v.client, noteID, v.projectID, "https://gcr.io/my-project/base", baseFingerprint); err != nil {
+		t.Fatalf("createImageNote(%s): %v", noteID, err)
+	}
+
+	fingerprint := &image.Fingerprint{V1Name: "sha256:derived", V2Blob: []string{"sha256:layer0", "sha256:layer1"}}
+	layers := []*image.Layer{{Directive: image.Layer_RUN, Arguments: "apt-get update"}}
+	created, err := createImageOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, fingerprint, layers)
+	if err != nil {
+		t.Errorf("createImageOccurrence(%s, %s): %v", v.imageUrl, noteID, err)
+	} else if got := created.GetDerivedImage().GetDerivedImage().GetFingerprint().GetV1Name(); got != fingerprint.V1Name {
+		t.Errorf("created occurrence has fingerprint: %s; want: %s", got, fingerprint.V1Name)
+	}
+
+	// Clean up
+	if created != nil {
+		deleteOccurrence(v.ctx, v.client, created.Name)
+	}
+	deleteNote(v.ctx, v.client, noteID, v.projectID)
+	teardown(t, v)
+}
+
+func TestPackageOccurrence(t *testing.T) {
+	v := setup(t)
+
+	noteID := "package-" + v.noteID
+	distributions := []*pkg.Distribution{{CpeUri: "cpe:/o:debian:debian_linux:9", Architecture: pkg.Architecture_X64}}
+	if _, err := createPackageNote(v.ctx, v.client, noteID, v.projectID, "", distributions); err == nil {
+		t.Error("expected error from createPackageNote without a package name; got nil")
+	}
+	if _, err := createPackageNote(v.ctx, v.client, noteID, v.projectID, "openssl", distributions); err != nil {
+		t.Fatalf("createPackageNote(%s): %v", noteID, err)
+	}
+
+	if _, err := createPackageOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, "openssl", &pkg.Location{}); err == nil {
+		t.Error("expected error from createPackageOccurrence without a version; got nil")
+	}
+	location := &pkg.Location{
+		CpeUri:  "cpe:/o:debian:debian_linux:9",
+		Version: &pkg.Version{Name: "1.1.0l", Revision: "1~deb9u1", Kind: pkg.Version_NORMAL},
+	}
+	created, err := createPackageOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, "openssl", location)
+	if err != nil {
+		t.Errorf("createPackageOccurrence(%s, %s): %v", v.imageUrl, noteID, err)
+	} else if got := created.GetInstallation().GetInstallation().GetName(); got != "openssl" {
+		t.Errorf("created occurrence has package: %s; want: openssl", got)
+	}
+
+	// Clean up
+	if created != nil {
+		deleteOccurrence(v.ctx, v.client, created.Name)
+	}
+	deleteNote(v.ctx, v.client, noteID, v.projectID)
+	teardown(t, v)
+}
+
+func TestDiscoveryOccurrence(t *testing.T) {
+	v := setup(t)
+
+	noteID := "discovery-" + v.noteID
+	if _, err := createDiscoveryNote(v.ctx, v.client, noteID, v.projectID, common.NoteKind_VULNERABILITY); err != nil {
+		t.Fatalf("createDiscoveryNote(%s): %v", noteID, err)
+	}
+
+	if _, err := createDiscoveryOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, discovery.Discovered_AnalysisStatus(42), discovery.Discovered_ACTIVE); err == nil {
+		t.Error("expected error from createDiscoveryOccurrence with an unknown status; got nil")
+	}
+	created, err := createDiscoveryOccurrence(v.ctx, v.client, v.imageUrl, noteID, v.projectID, v.projectID, discovery.Discovered_PENDING, discovery.Discovered_ACTIVE)
+	if err != nil {
+		t.Errorf("createDiscoveryOccurrence(%s, %s): %v", v.imageUrl, noteID, err)
+	} else if got := created.GetDiscovered().GetDiscovered().GetAnalysisStatus(); got != discovery.Discovered_PENDING {
+		t.Errorf("created occurrence has status: %v; want: %v", got, discovery.Discovered_PENDING)
+	}
+
+	if created != nil {
+		for _, status := range []discovery.Discovered_AnalysisStatus{discovery.Discovered_SCANNING, discovery.Discovered_FINISHED_SUCCESS} {
+			updated, err := updateDiscoveryOccurrenceStatus(v.ctx, v.client, created.Name, status)
+			if err != nil {
+				t.Errorf("updateDiscoveryOccurrenceStatus(%s, %v): %v", created.Name, status, err)
+			} else if got := updated.GetDiscovered().GetDiscovered().GetAnalysisStatus(); got != status {
+				t.Errorf("updated occurrence has status: %v; want: %v", got, status)
+			}
 		}
-		if newCount != 1 {
-			r.Errorf("unexpected updated number of occurrences: %d; want: %d", newCount, 1)
+		if _, err := updateDiscoveryOccurrenceStatus(v.ctx, v.client, created.Name, discovery.Discovered_SCANNING); err == nil {
+			t.Error("expected error from updateDiscoveryOccurrenceStatus moving a finished scan back to SCANNING; got nil")
 		}
-	})
+	}
 
 	// Clean up
-	deleteOccurrence(v.ctx, v.client, created.Name)
+	if created != nil {
+		deleteOccurrence(v.ctx, v.client, created.Name)
+	}
+	deleteNote(v.ctx, v.client, noteID, v.projectID)
 	teardown(t, v)
 }
 
-func TestOccurrencesForNote(t *testing.T) {
+func TestBatchCreateOccurrences(t *testing.T) {
 	v := setup(t)
 
-	origCount, err := getOccurrencesForNote(v.ctx, v.client, v.noteID, v.projectID)
+	var occs []*grafeaspb.Occurrence
+	for i := 0; i < 3; i++ {
+		occs = append(occs, &grafeaspb.Occurrence{
+			NoteName: v.noteObj.Name,
+			Resource: &grafeaspb.Resource{Uri: v.imageUrl},
+			Details: &grafeaspb.Occurrence_Vulnerability{
+				Vulnerability: &vulnerability.Details{},
+			},
+		})
+	}
+	created, err := batchCreateOccurrences(v.ctx, v.client, v.projectID, occs)
 	if err != nil {
-		t.Errorf("getOccurrenceForNote(%s): %v", v.noteID, err)
+		t.Errorf("batchCreateOccurrences(%s): %v", v.projectID, err)
 	}
-	if origCount != 0 {
-		t.Errorf("unexpected initial number of occurrences: %d; want: %d", origCount, 0)
+	if len(created) != len(occs) {
+		t.Errorf("batchCreateOccurrences created %d occurrences; want: %d", len(created), len(occs))
+	}
+
+	// Clean up
+	for _, occ := range created {
+		deleteOccurrence(v.ctx, v.client, occ.Name)
 	}
+	teardown(t, v)
+}
+
+func TestNoteForOccurrence(t *testing.T) {
+	v := setup(t)
+
 	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
 	if err != nil {
-		t.Errorf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
-	} else if created == nil {
-		t.Error("createOccurrence returns nil Occurrence object")
+		t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
 	}
-
 	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
-		newCount, err := getOccurrencesForNote(v.ctx, v.client, v.noteID, v.projectID)
+		note, err := getNoteForOccurrence(v.ctx, v.client, created.Name)
 		if err != nil {
-			r.Errorf("getOccurrencesForNote(%s): %v", v.noteID, err)
+			r.Errorf("getNoteForOccurrence(%s): %v", created.Name, err)
+			return
 		}
-		if newCount != 1 {
-			r.Errorf("unexpected updated number of occurrences: %d; want: %d", newCount, 1)
+		if note.Name != v.noteObj.Name {
+			r.Errorf("getNoteForOccurrence returned note %s; want: %s", note.Name, v.noteObj.Name)
 		}
 	})
 
 	// Clean up
 	deleteOccurrence(v.ctx, v.client, created.Name)
+	if _, err := getNoteForOccurrence(v.ctx, v.client, created.Name); err == nil {
+		t.Errorf("getNoteForOccurrence(%s) on a deleted occurrence returned nil error", created.Name)
+	}
 	teardown(t, v)
 }
 
-func TestPubSub(t *testing.T) {
-	t.Skip("Flaky: golang-samples#812")
+func TestDeleteOccurrence(t *testing.T) {
 	v := setup(t)
-	// Create a new subscription if it doesn't exist.
-	createOccurrenceSubscription(v.ctx, v.subID, v.projectID)
 
-	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
-		// Use a channel and a goroutine to count incomming messages.
-		c := make(chan int)
+	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Errorf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	} else if created == nil {
+		t.Error("createOccurrence returns nil Occurrence object")
+	} else {
+		err = deleteOccurrence(v.ctx, v.client, created.Name)
+		if err != nil {
+			t.Errorf("deleteOccurrence(%s): %v", created.Name, err)
+		}
+		deleted, err := getOccurrence(v.ctx, ioutil.Discard, v.client, created.Name, v.timeout)
+		if err == nil {
+			t.Error("getOccurrence returned nil error after DeleteOccurrence. expected error")
+		}
+		if deleted != nil {
+			t.Errorf("getOccurrence returned occurrence after deletion: %v; expected nil", deleted)
+		}
+	}
+
+	teardown(t, v)
+}
+
+// fakeOccurrenceCreator returns errs in order from CreateOccurrence, then succeeds.
+type fakeOccurrenceCreator struct {
+	unimplementedGrafeasClient
+	errs  []error
+	calls int
+}
+
+func (f *fakeOccurrenceCreator) CreateOccurrence(ctx context.Context, req *grafeaspb.CreateOccurrenceRequest, opts ...gax.CallOption) (*grafeaspb.Occurrence, error) {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return nil, f.errs[f.calls-1]
+	}
+	return &grafeaspb.Occurrence{Name: req.Parent + "/occurrences/fake", NoteName: req.Occurrence.NoteName}, nil
+}
+
+func TestPurgeOccurrencesForImage(t *testing.T) {
+	v := setup(t)
+
+	for i := 0; i < 2; i++ {
+		if _, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID); err != nil {
+			t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+		}
+	}
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		occs, err := getOccurrencesForImage(v.ctx, ioutil.Discard, v.client, v.imageUrl, v.projectID, v.timeout)
+		if err != nil {
+			r.Errorf("getOccurrencesForImage(%s): %v", v.imageUrl, err)
+		}
+		if len(occs) != 2 {
+			r.Errorf("unexpected number of occurrences: %d; want: %d", len(occs), 2)
+		}
+	})
+
+	deleted, err := purgeOccurrencesForImage(v.ctx, v.client, v.imageUrl, v.projectID)
+	if err != nil {
+		t.Errorf("purgeOccurrencesForImage(%s): %v", v.imageUrl, err)
+	}
+	if deleted != 2 {
+		t.Errorf("purgeOccurrencesForImage deleted %d occurrences; want: %d", deleted, 2)
+	}
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		occs, err := getOccurrencesForImage(v.ctx, ioutil.Discard, v.client, v.imageUrl, v.projectID, v.timeout)
+		if err != nil {
+			r.Errorf("getOccurrencesForImage(%s): %v", v.imageUrl, err)
+		}
+		if len(occs) != 0 {
+			r.Errorf("unexpected number of occurrences after purge: %d; want: %d", len(occs), 0)
+		}
+	})
+
+	teardown(t, v)
+}
+
+func TestCreateOccurrenceWithRetry(t *testing.T) {
+	ctx := context.Background()
+	defer func(b gax.Backoff) { createOccurrenceBackoff = b }(createOccurrenceBackoff)
+	createOccurrenceBackoff = gax.Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond, Multiplier: 2}
+
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	fake := &fakeOccurrenceCreator{unimplementedGrafeasClient: unimplementedGrafeasClient{t}, errs: []error{unavailable, unavailable}}
+	occ, err := createOccurrenceWithRetry(ctx, fake, "www.example.com", "my-note", "occ-project", "note-project", 5)
+	if err != nil {
+		t.Fatalf("createOccurrenceWithRetry: %v", err)
+	}
+	if fake.calls != 3 {
+		t.Errorf("CreateOccurrence called %d times; want: %d", fake.calls, 3)
+	}
+	if want := "projects/note-project/notes/my-note"; occ.NoteName != want {
+		t.Errorf("created occurrence has note name: %s; want: %s", occ.NoteName, want)
+	}
+
+	fake = &fakeOccurrenceCreator{unimplementedGrafeasClient: unimplementedGrafeasClient{t}, errs: []error{unavailable, unavailable}}
+	if _, err := createOccurrenceWithRetry(ctx, fake, "www.example.com", "my-note", "occ-project", "note-project", 2); err == nil {
+		t.Error("expected error from createOccurrenceWithRetry after exhausting attempts; got nil")
+	}
+	if fake.calls != 2 {
+		t.Errorf("CreateOccurrence called %d times; want: %d", fake.calls, 2)
+	}
+
+	fake = &fakeOccurrenceCreator{unimplementedGrafeasClient: unimplementedGrafeasClient{t}, errs: []error{status.Error(codes.InvalidArgument, "bad request")}}
+	if _, err := createOccurrenceWithRetry(ctx, fake, "www.example.com", "my-note", "occ-project", "note-project", 5); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("createOccurrenceWithRetry returned %v; want InvalidArgument error", err)
+	}
+	if fake.calls != 1 {
+		t.Errorf("CreateOccurrence called %d times for a non-retryable error; want: %d", fake.calls, 1)
+	}
+
+	fake = &fakeOccurrenceCreator{unimplementedGrafeasClient: unimplementedGrafeasClient{t}}
+	if _, err := createOccurrenceWithRetry(ctx, fake, "www.example.com", "my-note", "occ-project", "note-project", 0); err == nil {
+		t.Error("expected error from createOccurrenceWithRetry with maxAttempts 0; got nil")
+	}
+	if fake.calls != 0 {
+		t.Errorf("CreateOccurrence called %d times with maxAttempts 0; want: %d", fake.calls, 0)
+	}
+}
+
+// fakeNoteIamPolicyClient stores a single IAM policy in memory.
+type fakeNoteIamPolicyClient struct {
+	unimplementedIAMPolicyClient
+	policy   *iampb.Policy
+	setCalls int
+}
+
+func (f *fakeNoteIamPolicyClient) GetIamPolicy(ctx context.Context, req *iampb.GetIamPolicyRequest, opts ...gax.CallOption) (*iampb.Policy, error) {
+	return proto.Clone(f.policy).(*iampb.Policy), nil
+}
+
+func (f *fakeNoteIamPolicyClient) SetIamPolicy(ctx context.Context, req *iampb.SetIamPolicyRequest, opts ...gax.CallOption) (*iampb.Policy, error) {
+	f.setCalls++
+	if !bytes.Equal(req.Policy.Etag, f.policy.Etag) {
+		return nil, status.Error(codes.Aborted, "etag mismatch")
+	}
+	f.policy = proto.Clone(req.Policy).(*iampb.Policy)
+	f.policy.Etag = []byte("etag-" + strconv.Itoa(f.setCalls))
+	return f.policy, nil
+}
+
+func TestNoteIamPolicy(t *testing.T) {
+	ctx := context.Background()
+	role := "roles/containeranalysis.notes.attacher"
+	member := "serviceAccount:attacher@example.iam.gserviceaccount.com"
+	fake := &fakeNoteIamPolicyClient{unimplementedIAMPolicyClient: unimplementedIAMPolicyClient{t}, policy: &iampb.Policy{Etag: []byte("etag-0")}}
+
+	policy, err := setNoteIamPolicy(ctx, ioutil.Discard, fake, "my-note", "my-project", role, member)
+	if err != nil {
+		t.Fatalf("setNoteIamPolicy: %v", err)
+	}
+	if len(policy.Bindings) != 1 || policy.Bindings[0].Role != role || len(policy.Bindings[0].Members) != 1 {
+		t.Errorf("setNoteIamPolicy returned bindings %v; want a single %s binding for %s", policy.Bindings, role, member)
+	}
+
+	// Adding the same member again doesn't duplicate the binding or write the policy.
+	if _, err := setNoteIamPolicy(ctx, ioutil.Discard, fake, "my-note", "my-project", role, member); err != nil {
+		t.Fatalf("setNoteIamPolicy: %v", err)
+	}
+	if fake.setCalls != 1 {
+		t.Errorf("SetIamPolicy called %d times; want: %d", fake.setCalls, 1)
+	}
+
+	got, err := getNoteIamPolicy(ctx, ioutil.Discard, fake, "my-note", "my-project")
+	if err != nil {
+		t.Fatalf("getNoteIamPolicy: %v", err)
+	}
+	if members := got.Bindings[0].Members; len(members) != 1 || members[0] != member {
+		t.Errorf("getNoteIamPolicy returned members %v; want: [%s]", members, member)
+	}
+}
+
+// fakeIamPermissionsTester grants the permissions in granted and records the resources it was asked about.
+type fakeIamPermissionsTester struct {
+	unimplementedIAMPolicyClient
+	granted   map[string]bool
+	resources []string
+}
+
+func (f *fakeIamPermissionsTester) TestIamPermissions(ctx context.Context, req *iampb.TestIamPermissionsRequest, opts ...gax.CallOption) (*iampb.TestIamPermissionsResponse, error) {
+	f.resources = append(f.resources, req.Resource)
+	resp := &iampb.TestIamPermissionsResponse{}
+	for _, p := range req.Permissions {
+		if f.granted[p] {
+			resp.Permissions = append(resp.Permissions, p)
+		}
+	}
+	return resp, nil
+}
+
+func TestNoteAndOccurrencePermissions(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeIamPermissionsTester{unimplementedIAMPolicyClient: unimplementedIAMPolicyClient{t}, granted: map[string]bool{
+		"containeranalysis.notes.get":             true,
+		"containeranalysis.occurrences.get":       true,
+		"containeranalysis.occurrences.update":    false,
+		"containeranalysis.notes.listOccurrences": true,
+	}}
+
+	granted, err := testNotePermissions(ctx, ioutil.Discard, fake, "my-note", "my-project", []string{"containeranalysis.notes.get", "containeranalysis.notes.update"})
+	if err != nil {
+		t.Fatalf("testNotePermissions: %v", err)
+	}
+	if len(granted) != 1 || granted[0] != "containeranalysis.notes.get" {
+		t.Errorf("testNotePermissions returned %v; want: [containeranalysis.notes.get]", granted)
+	}
+
+	occurrenceName := "projects/my-project/occurrences/my-occurrence"
+	granted, err = testOccurrencePermissions(ctx, ioutil.Discard, fake, occurrenceName, []string{"containeranalysis.occurrences.get", "containeranalysis.occurrences.update"})
+	if err != nil {
+		t.Fatalf("testOccurrencePermissions: %v", err)
+	}
+	if len(granted) != 1 || granted[0] != "containeranalysis.occurrences.get" {
+		t.Errorf("testOccurrencePermissions returned %v; want: [containeranalysis.occurrences.get]", granted)
+	}
+
+	want := []string{"projects/my-project/notes/my-note", occurrenceName}
+	if len(fake.resources) != 2 || fake.resources[0] != want[0] || fake.resources[1] != want[1] {
+		t.Errorf("TestIamPermissions called on %v; want: %v", fake.resources, want)
+	}
+}
+
+func TestUpdateOccurrence(t *testing.T) {
+	t.Skip("Flaky. golang-samples#785")
+
+	v := setup(t)
+
+	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Errorf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	} else if created == nil {
+		t.Error("createOccurrence returns nil Occurrence object")
+	} else {
+		newType := "updated"
+
+		details := vulnerability.Details{Type: newType}
+		vulDetails := grafeaspb.Occurrence_Vulnerability{Vulnerability: &details}
+		resource := grafeaspb.Resource{Uri: created.Resource.Uri}
+		occurrence := grafeaspb.Occurrence{NoteName: created.NoteName, Resource: &resource, Details: &vulDetails}
+
+		returned, err := updateOccurrence(v.ctx, v.client, &occurrence, created.Name)
+		if err != nil {
+			t.Errorf("updateOccurrence(%s): %v", created.Name, err)
+		} else if returned.GetVulnerability().Type != newType {
+			t.Errorf("returned occurrence doesn't contain requested vulnerability type: %s; want: %s", returned.GetVulnerability().Type, newType)
+		}
+		retrieved, err := getOccurrence(v.ctx, ioutil.Discard, v.client, created.Name, v.timeout)
+		if err != nil {
+			t.Errorf("getOccurrence(%s): %v", created.Name, err)
+		} else if retrieved == nil {
+			t.Error("GetOccurrence returned nil Occurrence object")
+		} else if retrieved.GetVulnerability().Type != newType {
+			t.Errorf("updated occurrence doesn't contain requested vulnerability type: %s; want: %s", retrieved.GetVulnerability().Type, newType)
+		}
+	}
+	teardown(t, v)
+}
+
+func TestOccurrencesForImage(t *testing.T) {
+	v := setup(t)
+
+	origOccs, err := getOccurrencesForImage(v.ctx, ioutil.Discard, v.client, v.imageUrl, v.projectID, v.timeout)
+	if err != nil {
+		t.Errorf("getOccurrenceForImage(%s): %v", v.imageUrl, err)
+	}
+	if origCount := len(origOccs); origCount != 0 {
+		t.Errorf("unexpected initial number of occurrences: %d; want: %d", origCount, 0)
+	}
+	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Errorf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	} else if created == nil {
+		t.Error("createOccurrence returns nil Occurrence object")
+	}
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		newOccs, err := getOccurrencesForImage(v.ctx, ioutil.Discard, v.client, v.imageUrl, v.projectID, v.timeout)
+		if err != nil {
+			r.Errorf("getOccurrencesForImage(%s): %v", v.imageUrl, err)
+		}
+		if newCount := len(newOccs); newCount != 1 {
+			r.Errorf("unexpected updated number of occurrences: %d; want: %d", newCount, 1)
+			return
+		}
+		if got := newOccs[0].Name; got != created.Name {
+			r.Errorf("getOccurrencesForImage returned occurrence %s; want: %s", got, created.Name)
+		}
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	teardown(t, v)
+}
+
+func TestStreamOccurrencesForImage(t *testing.T) {
+	v := setup(t)
+
+	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		var names []string
+		err := streamOccurrencesForImage(v.ctx, v.client, v.imageUrl, v.projectID, v.timeout, func(occ *grafeaspb.Occurrence) error {
+			names = append(names, occ.Name)
+			return nil
+		})
+		if err != nil {
+			r.Errorf("streamOccurrencesForImage(%s): %v", v.imageUrl, err)
+			return
+		}
+		if len(names) != 1 || names[0] != created.Name {
+			r.Errorf("streamOccurrencesForImage visited %v; want: [%s]", names, created.Name)
+		}
+	})
+
+	errStop := errors.New("stop")
+	err = streamOccurrencesForImage(v.ctx, v.client, v.imageUrl, v.projectID, v.timeout, func(*grafeaspb.Occurrence) error { return errStop })
+	if err != errStop {
+		t.Errorf("streamOccurrencesForImage with a failing callback: %v; want: %v", err, errStop)
+	}
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	teardown(t, v)
+}
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		in                                string
+		registry, repository, tagOrDigest string
+	}{
+		{"gcr.io/my-project/my-image:v1", "gcr.io", "my-project/my-image", "v1"},
+		{"https://gcr.io/my-project/my-image", "gcr.io", "my-project/my-image", "latest"},
+		{"gcr.io/my-project/my-image@sha256:abc", "gcr.io", "my-project/my-image", "sha256:abc"},
+	}
+	for _, tc := range tests {
+		registry, repository, tagOrDigest, err := parseImageReference(tc.in)
+		if err != nil {
+			t.Errorf("parseImageReference(%q): %v", tc.in, err)
+			continue
+		}
+		if registry != tc.registry || repository != tc.repository || tagOrDigest != tc.tagOrDigest {
+			t.Errorf("parseImageReference(%q) = %q, %q, %q; want: %q, %q, %q", tc.in, registry, repository, tagOrDigest, tc.registry, tc.repository, tc.tagOrDigest)
+		}
+	}
+
+	for _, in := range []string{"", "gcr.io", "gcr.io/", "gcr.io/my-image:", "gcr.io/my-image@md5:abc"} {
+		if _, err := resolveImageDigest(context.Background(), in); err == nil {
+			t.Errorf("resolveImageDigest(%q) returned nil error for a malformed reference", in)
+		}
+	}
+}
+
+func TestOccurrencesForImageSince(t *testing.T) {
+	v := setup(t)
+
+	if occs, err := getOccurrencesForImageSince(v.ctx, v.client, v.imageUrl, v.projectID, time.Now().Add(time.Hour), v.timeout); err != nil || len(occs) != 0 {
+		t.Errorf("getOccurrencesForImageSince(%s) in the future: %d occurrences, %v; want: 0, nil", v.imageUrl, len(occs), err)
+	}
+
+	since := time.Now()
+	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		occs, err := getOccurrencesForImageSince(v.ctx, v.client, v.imageUrl, v.projectID, since.Add(-time.Minute), v.timeout)
+		if err != nil {
+			r.Errorf("getOccurrencesForImageSince(%s): %v", v.imageUrl, err)
+			return
+		}
+		if len(occs) != 1 {
+			r.Errorf("unexpected number of occurrences: %d; want: %d", len(occs), 1)
+		}
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	teardown(t, v)
+}
+
+func TestOccurrencesForImagePrefix(t *testing.T) {
+	v := setup(t)
+
+	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+
+	prefix := v.imageUrl[:len(v.imageUrl)-len(".com")]
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		occs, err := getOccurrencesForImagePrefix(v.ctx, v.client, prefix, v.projectID, v.timeout)
+		if err != nil {
+			r.Errorf("getOccurrencesForImagePrefix(%s): %v", prefix, err)
+			return
+		}
+		if len(occs) != 1 || occs[0].Name != created.Name {
+			r.Errorf("getOccurrencesForImagePrefix returned %d occurrences; want: [%s]", len(occs), created.Name)
+		}
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	teardown(t, v)
+}
+
+func TestOccurrencesByKind(t *testing.T) {
+	v := setup(t)
+
+	if _, err := listOccurrencesByKind(v.ctx, v.client, v.projectID, "NOT_A_KIND", v.timeout); err == nil {
+		t.Error("expected error from listOccurrencesByKind with an unknown kind; got nil")
+	}
+
+	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		occs, err := listOccurrencesByKind(v.ctx, v.client, v.projectID, "VULNERABILITY", v.timeout)
+		if err != nil {
+			r.Errorf("listOccurrencesByKind(VULNERABILITY): %v", err)
+			return
+		}
+		for _, occ := range occs {
+			if occ.Name == created.Name {
+				return
+			}
+		}
+		r.Errorf("listOccurrencesByKind(VULNERABILITY) did not return %s", created.Name)
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	teardown(t, v)
+}
+
+func TestOccurrencesForNote(t *testing.T) {
+	v := setup(t)
+
+	origOccs, err := getOccurrencesForNote(v.ctx, ioutil.Discard, v.client, v.noteID, v.projectID, 0, v.timeout)
+	if err != nil {
+		t.Errorf("getOccurrenceForNote(%s): %v", v.noteID, err)
+	}
+	if origCount := len(origOccs); origCount != 0 {
+		t.Errorf("unexpected initial number of occurrences: %d; want: %d", origCount, 0)
+	}
+	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Errorf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	} else if created == nil {
+		t.Error("createOccurrence returns nil Occurrence object")
+	}
+
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		newOccs, err := getOccurrencesForNote(v.ctx, ioutil.Discard, v.client, v.noteID, v.projectID, 1, v.timeout)
+		if err != nil {
+			r.Errorf("getOccurrencesForNote(%s): %v", v.noteID, err)
+		}
+		if newCount := len(newOccs); newCount != 1 {
+			r.Errorf("unexpected updated number of occurrences: %d; want: %d", newCount, 1)
+		}
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	teardown(t, v)
+}
+
+func TestPollDiscoveryOccurrenceFinished(t *testing.T) {
+	v := setup(t)
+
+	timeout := 5 * time.Second
+	if _, err := pollDiscoveryOccurrenceFinished(v.ctx, v.client, v.imageUrl, v.projectID, timeout); err == nil {
+		t.Error("expected error from pollDiscoveryOccurrenceFinished for an image without a discovery occurrence; got nil")
+	}
+
+	// Create a finished Discovery Occurrence for the image.
+	discoveryNoteID := "discovery-" + v.noteID
+	noteReq := &grafeaspb.CreateNoteRequest{
+		Parent: "projects/" + v.projectID,
+		NoteId: discoveryNoteID,
+		Note: &grafeaspb.Note{
+			Type: &grafeaspb.Note_Discovery{
+				Discovery: &discovery.Discovery{AnalysisKind: common.NoteKind_DISCOVERY},
+			},
+		},
+	}
+	if _, err := v.client.CreateNote(v.ctx, noteReq); err != nil {
+		t.Fatalf("CreateNote(%s): %v", discoveryNoteID, err)
+	}
+	occReq := &grafeaspb.CreateOccurrenceRequest{
+		Parent: "projects/" + v.projectID,
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: fmt.Sprintf("projects/%s/notes/%s", v.projectID, discoveryNoteID),
+			Resource: &grafeaspb.Resource{Uri: v.imageUrl},
+			Details: &grafeaspb.Occurrence_Discovered{
+				Discovered: &discovery.Details{
+					Discovered: &discovery.Discovered{AnalysisStatus: discovery.Discovered_FINISHED_SUCCESS},
+				},
+			},
+		},
+	}
+	created, err := v.client.CreateOccurrence(v.ctx, occReq)
+	if err != nil {
+		t.Fatalf("CreateOccurrence(%s, %s): %v", v.imageUrl, discoveryNoteID, err)
+	}
+
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		occ, err := pollDiscoveryOccurrenceFinished(v.ctx, v.client, v.imageUrl, v.projectID, timeout)
+		if err != nil {
+			r.Errorf("pollDiscoveryOccurrenceFinished(%s): %v", v.imageUrl, err)
+			return
+		}
+		if occ.Name != created.Name {
+			r.Errorf("pollDiscoveryOccurrenceFinished returned occurrence %s; want: %s", occ.Name, created.Name)
+		}
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	deleteNote(v.ctx, v.client, discoveryNoteID, v.projectID)
+	teardown(t, v)
+}
+
+func TestPollUntilVulnerabilitiesFound(t *testing.T) {
+	v := setup(t)
+
+	timeout := 5 * time.Second
+	if _, err := pollUntilVulnerabilitiesFound(v.ctx, v.client, v.imageUrl, v.projectID, timeout); err == nil {
+		t.Error("expected error from pollUntilVulnerabilitiesFound for an image without vulnerabilities; got nil")
+	}
+
+	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+
+	occs, err := pollUntilVulnerabilitiesFound(v.ctx, v.client, v.imageUrl, v.projectID, time.Duration(v.tryLimit)*time.Second)
+	if err != nil {
+		t.Errorf("pollUntilVulnerabilitiesFound(%s): %v", v.imageUrl, err)
+	} else if len(occs) != 1 {
+		t.Errorf("unexpected number of vulnerability occurrences: %d; want: %d", len(occs), 1)
+	}
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	teardown(t, v)
+}
+
+func TestHighSeverityOccurrencesForImage(t *testing.T) {
+	v := setup(t)
+
+	counts, err := summarizeVulnerabilityOccurrences(v.ctx, v.client, v.projectID, v.imageUrl, v.timeout)
+	if err != nil {
+		t.Errorf("summarizeVulnerabilityOccurrences(%s): %v", v.imageUrl, err)
+	} else if len(counts) != 0 {
+		t.Errorf("summarizeVulnerabilityOccurrences for a new image returned %v; want an empty map", counts)
+	}
+
+	low, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: "projects/" + v.projectID,
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: v.noteObj.Name,
+			Resource: &grafeaspb.Resource{Uri: v.imageUrl},
+			Details: &grafeaspb.Occurrence_Vulnerability{
+				Vulnerability: &vulnerability.Details{Severity: vulnerability.Severity_CRITICAL},
+			},
+		},
+	}
+	critical, err := v.client.CreateOccurrence(v.ctx, req)
+	if err != nil {
+		t.Fatalf("CreateOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		counts, err := summarizeVulnerabilityOccurrences(v.ctx, v.client, v.projectID, v.imageUrl, v.timeout)
+		if err != nil {
+			r.Errorf("summarizeVulnerabilityOccurrences(%s): %v", v.imageUrl, err)
+			return
+		}
+		if got := counts[vulnerability.Severity_CRITICAL]; got != 1 {
+			r.Errorf("summarizeVulnerabilityOccurrences counted %d CRITICAL occurrences; want: %d", got, 1)
+		}
+		if got := counts[vulnerability.Severity_SEVERITY_UNSPECIFIED]; got != 1 {
+			r.Errorf("summarizeVulnerabilityOccurrences counted %d unspecified severity occurrences; want: %d", got, 1)
+		}
+	})
+
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		occs, err := getHighSeverityOccurrencesForImage(v.ctx, v.client, v.imageUrl, v.projectID, vulnerability.Severity_HIGH, v.timeout)
+		if err != nil {
+			r.Errorf("getHighSeverityOccurrencesForImage(%s): %v", v.imageUrl, err)
+			return
+		}
+		if len(occs) != 1 {
+			r.Errorf("unexpected number of high severity occurrences: %d; want: %d", len(occs), 1)
+			return
+		}
+		if occs[0].Name != critical.Name {
+			r.Errorf("getHighSeverityOccurrencesForImage returned occurrence %s; want: %s", occs[0].Name, critical.Name)
+		}
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, low.Name)
+	deleteOccurrence(v.ctx, v.client, critical.Name)
+	teardown(t, v)
+}
+
+func TestFailIfVulnerabilitiesAboveThreshold(t *testing.T) {
+	v := setup(t)
+
+	// Mark the image as scanned.
+	discoveryNoteID := "discovery-" + v.noteID
+	if _, err := createDiscoveryNote(v.ctx, v.client, discoveryNoteID, v.projectID, common.NoteKind_DISCOVERY); err != nil {
+		t.Fatalf("createDiscoveryNote(%s): %v", discoveryNoteID, err)
+	}
+	discovered, err := createDiscoveryOccurrence(v.ctx, v.client, v.imageUrl, discoveryNoteID, v.projectID, v.projectID, discovery.Discovered_FINISHED_SUCCESS, discovery.Discovered_INACTIVE)
+	if err != nil {
+		t.Fatalf("createDiscoveryOccurrence(%s): %v", v.imageUrl, err)
+	}
+
+	low, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		if err := failIfVulnerabilitiesAboveThreshold(v.ctx, v.client, v.projectID, v.imageUrl, vulnerability.Severity_HIGH, v.timeout); err != nil {
+			r.Errorf("failIfVulnerabilitiesAboveThreshold(%s) with only low severity vulnerabilities: %v", v.imageUrl, err)
+		}
+	})
+
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: "projects/" + v.projectID,
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: v.noteObj.Name,
+			Resource: &grafeaspb.Resource{Uri: v.imageUrl},
+			Details: &grafeaspb.Occurrence_Vulnerability{
+				Vulnerability: &vulnerability.Details{Severity: vulnerability.Severity_CRITICAL},
+			},
+		},
+	}
+	critical, err := v.client.CreateOccurrence(v.ctx, req)
+	if err != nil {
+		t.Fatalf("CreateOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		err := failIfVulnerabilitiesAboveThreshold(v.ctx, v.client, v.projectID, v.imageUrl, vulnerability.Severity_HIGH, v.timeout)
+		if err == nil || !strings.Contains(err.Error(), v.noteID) {
+			r.Errorf("failIfVulnerabilitiesAboveThreshold(%s) with a critical vulnerability: %v; want an error listing %s", v.imageUrl, err, v.noteID)
+		}
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, low.Name)
+	deleteOccurrence(v.ctx, v.client, critical.Name)
+	deleteOccurrence(v.ctx, v.client, discovered.Name)
+	deleteNote(v.ctx, v.client, discoveryNoteID, v.projectID)
+	teardown(t, v)
+}
+
+func TestListFixableVulnerabilities(t *testing.T) {
+	v := setup(t)
+
+	vulnOccurrence := func(fixedKind pkg.Version_VersionKind) *grafeaspb.CreateOccurrenceRequest {
+		return &grafeaspb.CreateOccurrenceRequest{
+			Parent: "projects/" + v.projectID,
+			Occurrence: &grafeaspb.Occurrence{
+				NoteName: v.noteObj.Name,
+				Resource: &grafeaspb.Resource{Uri: v.imageUrl},
+				Details: &grafeaspb.Occurrence_Vulnerability{
+					Vulnerability: &vulnerability.Details{
+						PackageIssue: []*vulnerability.PackageIssue{{
+							AffectedLocation: &vulnerability.VulnerabilityLocation{
+								CpeUri:  "cpe:/o:debian:debian_linux:9",
+								Package: "openssl",
+								Version: &pkg.Version{Name: "1.1.0", Kind: pkg.Version_NORMAL},
+							},
+							FixedLocation: &vulnerability.VulnerabilityLocation{
+								CpeUri:  "cpe:/o:debian:debian_linux:9",
+								Package: "openssl",
+								Version: &pkg.Version{Name: "1.1.1", Kind: fixedKind},
+							},
+						}},
+					},
+				},
+			},
+		}
+	}
+	fixable, err := v.client.CreateOccurrence(v.ctx, vulnOccurrence(pkg.Version_NORMAL))
+	if err != nil {
+		t.Fatalf("CreateOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+	unfixable, err := v.client.CreateOccurrence(v.ctx, vulnOccurrence(pkg.Version_MAXIMUM))
+	if err != nil {
+		t.Fatalf("CreateOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		occs, err := listFixableVulnerabilities(v.ctx, v.client, v.projectID, v.imageUrl, v.timeout)
+		if err != nil {
+			r.Errorf("listFixableVulnerabilities(%s): %v", v.imageUrl, err)
+			return
+		}
+		if len(occs) != 1 || occs[0].Name != fixable.Name {
+			r.Errorf("listFixableVulnerabilities(%s) returned %d occurrences; want only %s", v.imageUrl, len(occs), fixable.Name)
+		}
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, fixable.Name)
+	deleteOccurrence(v.ctx, v.client, unfixable.Name)
+	teardown(t, v)
+}
+
+func TestOccurrenceReceiveOptions(t *testing.T) {
+	sub := &pubsub.Subscription{}
+	sub.ReceiveSettings.NumGoroutines = 7
+
+	occurrenceReceiveOptions{maxOutstandingMessages: 10}.apply(sub)
+	if got, want := sub.ReceiveSettings.MaxOutstandingMessages, 10; got != want {
+		t.Errorf("MaxOutstandingMessages = %d; want: %d", got, want)
+	}
+	if got, want := sub.ReceiveSettings.NumGoroutines, 7; got != want {
+		t.Errorf("NumGoroutines = %d; want unchanged: %d", got, want)
+	}
+
+	occurrenceReceiveOptions{numGoroutines: 1}.apply(sub)
+	if got, want := sub.ReceiveSettings.NumGoroutines, 1; got != want {
+		t.Errorf("NumGoroutines = %d; want: %d", got, want)
+	}
+}
+
+func TestVulnerabilityOccurrencesSummary(t *testing.T) {
+	v := setup(t)
+
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: "projects/" + v.projectID,
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: v.noteObj.Name,
+			Resource: &grafeaspb.Resource{Uri: v.imageUrl},
+			Details: &grafeaspb.Occurrence_Vulnerability{
+				Vulnerability: &vulnerability.Details{Severity: vulnerability.Severity_HIGH},
+			},
+		},
+	}
+	created, err := v.client.CreateOccurrence(v.ctx, req)
+	if err != nil {
+		t.Fatalf("CreateOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		buf := &bytes.Buffer{}
+		summary, err := getVulnerabilityOccurrencesSummary(v.ctx, buf, v.client, v.projectID, v.imageUrl)
+		if err != nil {
+			r.Errorf("getVulnerabilityOccurrencesSummary(%s): %v", v.imageUrl, err)
+			return
+		}
+		var high int64
+		for _, c := range summary.GetCounts() {
+			if c.Severity == vulnerability.Severity_HIGH {
+				high += c.TotalCount
+			}
+		}
+		if high != 1 {
+			r.Errorf("getVulnerabilityOccurrencesSummary returned %d HIGH occurrences; want: 1", high)
+		}
+		if got, want := buf.String(), "HIGH: 1 total"; !strings.Contains(got, want) {
+			r.Errorf("getVulnerabilityOccurrencesSummary output %q; want to contain: %s", got, want)
+		}
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	teardown(t, v)
+}
+
+func TestVulnerabilityCVSSScores(t *testing.T) {
+	v := setup(t)
+
+	unscored, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: "projects/" + v.projectID,
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: v.noteObj.Name,
+			Resource: &grafeaspb.Resource{Uri: v.imageUrl},
+			Details: &grafeaspb.Occurrence_Vulnerability{
+				Vulnerability: &vulnerability.Details{Severity: vulnerability.Severity_HIGH, CvssScore: 7.5},
+			},
+		},
+	}
+	scored, err := v.client.CreateOccurrence(v.ctx, req)
+	if err != nil {
+		t.Fatalf("CreateOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		scores, err := getVulnerabilityCVSSScores(v.ctx, v.client, v.projectID, v.imageUrl, v.timeout)
+		if err != nil {
+			r.Errorf("getVulnerabilityCVSSScores(%s): %v", v.imageUrl, err)
+			return
+		}
+		want := vulnerabilityScore{Name: v.noteID, CVSS: 7.5, Severity: vulnerability.Severity_HIGH}
+		if len(scores) != 1 || scores[0] != want {
+			r.Errorf("getVulnerabilityCVSSScores returned %+v; want: [%+v]", scores, want)
+		}
+	})
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, unscored.Name)
+	deleteOccurrence(v.ctx, v.client, scored.Name)
+	teardown(t, v)
+}
+
+func TestOccurrencesForNoteAcrossProjects(t *testing.T) {
+	v := setup(t)
+
+	created, err := createOccurrence(v.ctx, v.client, v.imageUrl, v.noteID, v.projectID, v.projectID)
+	if err != nil {
+		t.Fatalf("createOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		count, err := getOccurrencesForNoteAcrossProjects(v.ctx, v.client, v.noteID, v.projectID, []string{v.projectID}, v.timeout)
+		if err != nil {
+			r.Errorf("getOccurrencesForNoteAcrossProjects(%s): %v", v.noteID, err)
+		}
+		if count != 1 {
+			r.Errorf("unexpected number of occurrences: %d; want: %d", count, 1)
+		}
+	})
+
+	// Occurrences are only counted in the requested projects.
+	count, err := getOccurrencesForNoteAcrossProjects(v.ctx, v.client, v.noteID, v.projectID, []string{"other-project"}, v.timeout)
+	if err != nil {
+		t.Errorf("getOccurrencesForNoteAcrossProjects(%s): %v", v.noteID, err)
+	}
+	if count != 0 {
+		t.Errorf("unexpected number of occurrences in other-project: %d; want: %d", count, 0)
+	}
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	teardown(t, v)
+}
+
+func TestExportSBOM(t *testing.T) {
+	if _, err := exportSBOM(context.Background(), ioutil.Discard, nil, "my-project", "www.example.com", "xml", 0); err == nil {
+		t.Error("expected error from exportSBOM for an unsupported format; got nil")
+	}
+
+	v := setup(t)
+
+	req := &grafeaspb.CreateOccurrenceRequest{
+		Parent: "projects/" + v.projectID,
+		Occurrence: &grafeaspb.Occurrence{
+			NoteName: v.noteObj.Name,
+			Resource: &grafeaspb.Resource{Uri: v.imageUrl},
+			Details: &grafeaspb.Occurrence_Vulnerability{
+				Vulnerability: &vulnerability.Details{
+					Severity: vulnerability.Severity_HIGH,
+					PackageIssue: []*vulnerability.PackageIssue{{
+						AffectedLocation: &vulnerability.VulnerabilityLocation{
+							CpeUri:  "cpe:/o:debian:debian_linux:9",
+							Package: "openssl",
+							Version: &pkg.Version{Name: "1.1.0", Revision: "1", Kind: pkg.Version_NORMAL},
+						},
+					}},
+				},
+			},
+		},
+	}
+	created, err := v.client.CreateOccurrence(v.ctx, req)
+	if err != nil {
+		t.Fatalf("CreateOccurrence(%s, %s): %v", v.imageUrl, v.noteID, err)
+	}
+
+	for _, format := range []string{"cyclonedx", "spdx"} {
+		testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+			buf := &bytes.Buffer{}
+			b, err := exportSBOM(v.ctx, buf, v.client, v.projectID, v.imageUrl, format, v.timeout)
+			if err != nil {
+				r.Errorf("exportSBOM(%s, %s): %v", v.imageUrl, format, err)
+				return
+			}
+			if !bytes.Equal(b, buf.Bytes()) {
+				r.Errorf("exportSBOM(%s, %s) wrote different bytes than it returned", v.imageUrl, format)
+			}
+			var sbom map[string]interface{}
+			if err := json.Unmarshal(b, &sbom); err != nil {
+				r.Errorf("exportSBOM(%s, %s) returned invalid JSON: %v", v.imageUrl, format, err)
+			}
+			wants := []string{"openssl", "1.1.0-1"}
+			if format == "cyclonedx" {
+				// SPDX only records CVE vulnerabilities, and the test Note isn't named after a CVE.
+				wants = append(wants, v.noteID)
+			}
+			for _, want := range wants {
+				if !bytes.Contains(b, []byte(want)) {
+					r.Errorf("exportSBOM(%s, %s) output doesn't contain: %s", v.imageUrl, format, want)
+				}
+			}
+		})
+	}
+
+	// Clean up
+	deleteOccurrence(v.ctx, v.client, created.Name)
+	teardown(t, v)
+}
+
+func TestSBOMDocuments(t *testing.T) {
+	// Two packages that differ only in their CPE URI must stay distinct.
+	debian := sbomPackage{name: "openssl", version: "1.1.0-1", cpeURI: "cpe:/o:debian:debian_linux:9"}
+	ubuntu := sbomPackage{name: "openssl", version: "1.1.0-1", cpeURI: "cpe:2.3:o:canonical:ubuntu_linux:18.04:*:*:*:*:*:*:*"}
+	packages := []sbomPackage{debian, ubuntu}
+	vulns := []sbomVulnerability{
+		{id: "CVE-2020-0001", cvss: 9.8, severity: vulnerability.Severity_CRITICAL, affects: debian},
+		{id: "CVE-2020-0002", severity: vulnerability.Severity_MINIMAL, affects: ubuntu},
+		{id: "GHSA-xxxx", severity: vulnerability.Severity_SEVERITY_UNSPECIFIED, affects: ubuntu},
+	}
+
+	// roundTrip marshals doc and decodes it again, as a consumer of the SBOM would see it.
+	roundTrip := func(doc map[string]interface{}) map[string]interface{} {
+		b, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatalf("json.Marshal: %v", err)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("json.Unmarshal: %v", err)
+		}
+		return got
+	}
+
+	bom := roundTrip(cycloneDXBOM("https://gcr.io/my-project/my-image", packages, vulns))
+	for _, field := range []string{"bomFormat", "specVersion", "version", "metadata", "components", "vulnerabilities"} {
+		if _, ok := bom[field]; !ok {
+			t.Errorf("CycloneDX BOM is missing required field %q", field)
+		}
+	}
+	refs := make(map[string]bool)
+	for _, c := range bom["components"].([]interface{}) {
+		ref := c.(map[string]interface{})["bom-ref"].(string)
+		if refs[ref] {
+			t.Errorf("CycloneDX BOM has duplicate bom-ref %q", ref)
+		}
+		refs[ref] = true
+	}
+	var severities []string
+	for _, v := range bom["vulnerabilities"].([]interface{}) {
+		vuln := v.(map[string]interface{})
+		ref := vuln["affects"].([]interface{})[0].(map[string]interface{})["ref"].(string)
+		if !refs[ref] {
+			t.Errorf("CycloneDX vulnerability %v affects unknown bom-ref %q", vuln["id"], ref)
+		}
+		severities = append(severities, vuln["ratings"].([]interface{})[0].(map[string]interface{})["severity"].(string))
+	}
+	if want := []string{"critical", "info", "unknown"}; strings.Join(severities, ",") != strings.Join(want, ",") {
+		t.Errorf("CycloneDX severities: %v; want: %v", severities, want)
+	}
+
+	empty := roundTrip(cycloneDXBOM("https://gcr.io/my-project/my-image", nil, nil))
+	for _, field := range []string{"components", "vulnerabilities"} {
+		if list, ok := empty[field].([]interface{}); !ok || len(list) != 0 {
+			t.Errorf("CycloneDX BOM with no packages has %s: %v; want: []", field, empty[field])
+		}
+	}
+
+	doc := roundTrip(spdxDocument("https://gcr.io/my-project/my-image", packages, vulns))
+	if doc["spdxVersion"] != "SPDX-2.3" {
+		t.Errorf("SPDX document has spdxVersion: %v; want: SPDX-2.3", doc["spdxVersion"])
+	}
+	for _, field := range []string{"dataLicense", "SPDXID", "name", "documentNamespace", "creationInfo", "documentDescribes", "packages"} {
+		if _, ok := doc[field]; !ok {
+			t.Errorf("SPDX document is missing required field %q", field)
+		}
+	}
+	spdxPackages := doc["packages"].([]interface{})
+	if len(spdxPackages) != 2 || len(doc["documentDescribes"].([]interface{})) != 2 {
+		t.Fatalf("SPDX document has %d packages and describes %v; want 2 of each", len(spdxPackages), doc["documentDescribes"])
+	}
+	var refTypes []string
+	for _, p := range spdxPackages {
+		spdxPackage := p.(map[string]interface{})
+		for _, field := range []string{"SPDXID", "name", "downloadLocation", "filesAnalyzed", "licenseConcluded", "licenseDeclared", "copyrightText"} {
+			if _, ok := spdxPackage[field]; !ok {
+				t.Errorf("SPDX package %v is missing required field %q", spdxPackage["SPDXID"], field)
+			}
+		}
+		for _, r := range spdxPackage["externalRefs"].([]interface{}) {
+			refTypes = append(refTypes, r.(map[string]interface{})["referenceType"].(string))
+		}
+	}
+	if want := []string{"cpe22Type", "advisory", "cpe23Type", "advisory"}; strings.Join(refTypes, ",") != strings.Join(want, ",") {
+		t.Errorf("SPDX external reference types: %v; want: %v", refTypes, want)
+	}
+}
+
+func TestDiffOccurrences(t *testing.T) {
+	vuln := func(name, note, pkgName string) *grafeaspb.Occurrence {
+		return &grafeaspb.Occurrence{
+			Name:     name,
+			NoteName: note,
+			Details: &grafeaspb.Occurrence_Vulnerability{
+				Vulnerability: &vulnerability.Details{
+					PackageIssue: []*vulnerability.PackageIssue{{
+						AffectedLocation: &vulnerability.VulnerabilityLocation{CpeUri: "cpe:/o:debian:debian_linux:9", Package: pkgName},
+					}},
+				},
+			},
+		}
+	}
+	oldOccs := []*grafeaspb.Occurrence{
+		vuln("old-1", "projects/goog-vulnz/notes/CVE-1", "openssl"),
+		vuln("old-2", "projects/goog-vulnz/notes/CVE-2", "curl"),
+		vuln("old-3", "projects/goog-vulnz/notes/CVE-2", "curl"),
+		vuln("old-4", "", "zlib"),
+	}
+	newOccs := []*grafeaspb.Occurrence{
+		vuln("new-1", "projects/goog-vulnz/notes/CVE-1", "openssl"),
+		vuln("new-2", "projects/goog-vulnz/notes/CVE-3", "bash"),
+		vuln("new-3", "projects/goog-vulnz/notes/CVE-3", "bash"),
+		vuln("new-4", "projects/goog-vulnz/notes/CVE-1", "libssl"),
+		vuln("new-5", "", "zlib"),
+		{Name: "new-6", NoteName: "projects/my-project/notes/build"},
+	}
+
+	added, removed := diffOccurrences(oldOccs, newOccs)
+	names := func(occs []*grafeaspb.Occurrence) []string {
+		var out []string
+		for _, occ := range occs {
+			out = append(out, occ.Name)
+		}
+		return out
+	}
+	if got, want := strings.Join(names(added), ","), "new-2,new-4,new-5"; got != want {
+		t.Errorf("diffOccurrences added = %s; want: %s", got, want)
+	}
+	if got, want := strings.Join(names(removed), ","), "old-2"; got != want {
+		t.Errorf("diffOccurrences removed = %s; want: %s", got, want)
+	}
+}
+
+func TestWriteOccurrenceTable(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := writeOccurrenceTable(buf, nil); err != nil {
+		t.Fatalf("writeOccurrenceTable with no occurrences: %v", err)
+	}
+	if got, want := buf.String(), "No occurrences found.\n"; got != want {
+		t.Errorf("writeOccurrenceTable with no occurrences wrote %q; want: %q", got, want)
+	}
+
+	occs := []*grafeaspb.Occurrence{
+		{
+			Resource: &grafeaspb.Resource{Uri: "https://gcr.io/my-project/my-image@sha256:abc"},
+			NoteName: "projects/goog-vulnz/notes/CVE-2019-1234",
+			Kind:     common.NoteKind_VULNERABILITY,
+			Details: &grafeaspb.Occurrence_Vulnerability{
+				Vulnerability: &vulnerability.Details{Severity: vulnerability.Severity_HIGH},
+			},
+		},
+		{
+			Resource: &grafeaspb.Resource{Uri: "https://gcr.io/my-project/my-image@sha256:abc"},
+			NoteName: "projects/my-project/notes/my-builder",
+			Kind:     common.NoteKind_BUILD,
+		},
+	}
+	buf.Reset()
+	if err := writeOccurrenceTable(buf, occs); err != nil {
+		t.Fatalf("writeOccurrenceTable: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("writeOccurrenceTable wrote %d lines; want: 3\n%s", len(lines), buf.String())
+	}
+	for i, want := range [][]string{
+		{"RESOURCE", "KIND", "SEVERITY", "NOTE"},
+		{"VULNERABILITY", "HIGH", "CVE-2019-1234"},
+		{"BUILD", "-", "my-builder"},
+	} {
+		for _, field := range want {
+			if !strings.Contains(lines[i], field) {
+				t.Errorf("writeOccurrenceTable line %d = %q; want it to contain %q", i, lines[i], field)
+			}
+		}
+	}
+	// The columns are aligned.
+	if got, want := strings.Index(lines[2], "BUILD"), strings.Index(lines[0], "KIND"); got != want {
+		t.Errorf("writeOccurrenceTable KIND column starts at %d in a row and %d in the header", got, want)
+	}
+}
+
+func TestWriteOccurrencesJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := writeOccurrencesJSON(buf, nil); err != nil {
+		t.Fatalf("writeOccurrencesJSON with no occurrences: %v", err)
+	}
+	if got, want := strings.TrimSpace(buf.String()), "[]"; got != want {
+		t.Errorf("writeOccurrencesJSON with no occurrences wrote %q; want: %q", got, want)
+	}
+
+	createTime, err := ptypes.TimestampProto(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	occs := []*grafeaspb.Occurrence{{
+		Name:       "projects/my-project/occurrences/my-occurrence",
+		Resource:   &grafeaspb.Resource{Uri: "https://gcr.io/my-project/my-image@sha256:abc"},
+		NoteName:   "projects/goog-vulnz/notes/CVE-2019-1234",
+		Kind:       common.NoteKind_VULNERABILITY,
+		CreateTime: createTime,
+		Details: &grafeaspb.Occurrence_Vulnerability{
+			Vulnerability: &vulnerability.Details{Severity: vulnerability.Severity_HIGH},
+		},
+	}}
+	buf.Reset()
+	if err := writeOccurrencesJSON(buf, occs); err != nil {
+		t.Fatalf("writeOccurrencesJSON: %v", err)
+	}
+	var got []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("writeOccurrencesJSON wrote invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(got) != 1 {
+		t.Fatalf("writeOccurrencesJSON wrote %d occurrences; want: 1", len(got))
+	}
+	if got[0]["kind"] != "VULNERABILITY" || got[0]["createTime"] != "2020-01-02T03:04:05Z" || got[0]["noteName"] != occs[0].NoteName {
+		t.Errorf("writeOccurrencesJSON wrote %v; want enum names, RFC 3339 timestamps and lowerCamelCase fields", got[0])
+	}
+	if !strings.Contains(buf.String(), "\n  ") {
+		t.Errorf("writeOccurrencesJSON output is not indented:\n%s", buf.String())
+	}
+}
+
+func TestPubSub(t *testing.T) {
+	t.Skip("Flaky: golang-samples#812")
+	v := setup(t)
+	// Create a new subscription if it doesn't exist.
+	if _, err := createOccurrenceSubscription(v.ctx, ioutil.Discard, v.subID, v.projectID, false, nil); err != nil {
+		t.Fatalf("createOccurrenceSubscription(%s): %v", v.subID, err)
+	}
+	// Creating it again returns the existing subscription.
+	if _, err := createOccurrenceSubscription(v.ctx, ioutil.Discard, v.subID, v.projectID, false, nil); err != nil {
+		t.Errorf("createOccurrenceSubscription(%s) on an existing subscription: %v", v.subID, err)
+	}
+	// Message ordering can't be changed on the existing subscription.
+	if _, err := createOccurrenceSubscription(v.ctx, ioutil.Discard, v.subID, v.projectID, true, nil); err == nil {
+		t.Errorf("createOccurrenceSubscription(%s) with different message ordering: got nil error; want error", v.subID)
+	}
+
+	testutil.Retry(t, v.tryLimit, time.Second, func(r *testutil.R) {
+		// Use a channel and a goroutine to count incomming messages.
+		c := make(chan int)
 		go func() {
-			count, err := occurrencePubsub(v.ctx, v.subID, 20, v.projectID)
+			handler := func(ctx context.Context, msg *pubsub.Message) error { return nil }
+			count, failed, err := occurrencePubsub(v.ctx, ioutil.Discard, v.subID, 20*time.Second, v.projectID, handler, occurrenceReceiveOptions{})
 			if err != nil {
 				t.Errorf("occurrencePubsub(%s): %v", v.subID, err)
 			}
+			if failed != 0 {
+				t.Errorf("occurrencePubsub(%s) failed to process %d messages; want: 0", v.subID, failed)
+			}
 			c <- count
 		}()
 
@@ -307,9 +1915,51 @@ func TestPubSub(t *testing.T) {
 		}
 	})
 
+	// With no new Occurrences, errorOnNoMessages reports the silence as an error.
+	handler := func(ctx context.Context, msg *pubsub.Message) error { return nil }
+	if _, _, err := occurrencePubsub(v.ctx, ioutil.Discard, v.subID, 5*time.Second, v.projectID, handler, occurrenceReceiveOptions{errorOnNoMessages: true}); err != errNoOccurrenceMessages {
+		t.Errorf("occurrencePubsub(%s) with no messages: %v; want: %v", v.subID, err, errNoOccurrenceMessages)
+	}
+
 	// Clean up
-	client, _ := pubsub.NewClient(v.ctx, v.projectID)
-	sub := client.Subscription(v.subID)
-	sub.Delete(v.ctx)
+	if err := deleteOccurrenceSubscription(v.ctx, v.subID, v.projectID); err != nil {
+		t.Errorf("deleteOccurrenceSubscription(%s): %v", v.subID, err)
+	}
+	// Deleting it again is not an error.
+	if err := deleteOccurrenceSubscription(v.ctx, v.subID, v.projectID); err != nil {
+		t.Errorf("deleteOccurrenceSubscription(%s) on a deleted subscription: %v", v.subID, err)
+	}
 	teardown(t, v)
 }
+
+func TestDispatchOccurrences(t *testing.T) {
+	ctx := context.Background()
+	if _, err := dispatchOccurrences(ctx, "my-sub", "my-project", "slack.example.com/hook", time.Minute); err == nil {
+		t.Error("expected error from dispatchOccurrences for a webhook URL without a scheme; got nil")
+	}
+	if _, err := dispatchOccurrences(ctx, "my-sub", "my-project", "https://hooks.example.com/hook", 0); err == nil {
+		t.Error("expected error from dispatchOccurrences with a zero timeout; got nil")
+	}
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		got = append(got, string(body))
+		if strings.Contains(string(body), "reject") {
+			http.Error(w, "rejected", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	ok := &pubsub.Message{Data: []byte(`{"name":"occurrence-1"}`)}
+	if err := postOccurrenceToWebhook(ctx, srv.Client(), srv.URL, ok); err != nil {
+		t.Errorf("postOccurrenceToWebhook(%s): %v", ok.Data, err)
+	}
+	rejected := &pubsub.Message{Data: []byte(`{"name":"reject"}`)}
+	if err := postOccurrenceToWebhook(ctx, srv.Client(), srv.URL, rejected); err == nil {
+		t.Errorf("expected error from postOccurrenceToWebhook(%s) for a 503 response; got nil", rejected.Data)
+	}
+	if len(got) != 2 || got[0] != string(ok.Data) {
+		t.Errorf("webhook received %q; want: [%q %q]", got, ok.Data, rejected.Data)
+	}
+}
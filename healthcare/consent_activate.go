@@ -0,0 +1,55 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_activate_consent]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// activateConsent activates a DRAFT consent, recording consentArtifactName as
+// the proof of the user's consent. The consent expires after ttl, such as
+// "86400s".
+func activateConsent(w io.Writer, projectID, location, datasetID, consentStoreID, consentID, consentArtifactName, ttl string) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	consentsService := healthcareService.Projects.Locations.Datasets.ConsentStores.Consents
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s/consents/%s", projectID, location, datasetID, consentStoreID, consentID)
+
+	req := &healthcare.ActivateConsentRequest{
+		ConsentArtifact: consentArtifactName,
+		Ttl:             ttl,
+	}
+
+	resp, err := consentsService.Activate(name, req).Do()
+	if err != nil {
+		return fmt.Errorf("Activate: %v", err)
+	}
+
+	fmt.Fprintf(w, "Activated consent: %q (state: %s)\n", resp.Name, resp.State)
+	return nil
+}
+
+// [END healthcare_activate_consent]
@@ -0,0 +1,164 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_apply_consent_admin_rules]
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// consentRequest describes a consent for applyConsentAdminRules to create.
+// ConsentArtifactName is the full resource name of an existing consent
+// artifact, State is a consent state such as "ACTIVE" or "DRAFT",
+// ResourceAttributes maps RESOURCE attribute definition IDs to the value the
+// consent applies to, and AuthorizationRule is a CEL expression over REQUEST
+// attributes.
+type consentRequest struct {
+	UserID              string
+	ConsentArtifactName string
+	State               string
+	ResourceAttributes  map[string]string
+	AuthorizationRule   string
+}
+
+// userDataMappingRequest describes a user data mapping for
+// applyConsentAdminRules to create. ResourceAttributes maps RESOURCE attribute
+// definition IDs to the value that describes the data identified by DataID.
+type userDataMappingRequest struct {
+	DataID             string
+	UserID             string
+	ResourceAttributes map[string]string
+}
+
+// createdResource is a consent or user data mapping that
+// applyConsentAdminRules created, and must delete again on rollback.
+type createdResource struct {
+	name    string
+	mapping bool
+}
+
+// applyConsentAdminRules creates consents and then user data mappings, in
+// order, as a single unit, such as when onboarding a patient. If a step
+// fails, the consents and mappings already created are deleted again.
+//
+// It returns the names of the created resources that remain, in the order
+// they were created: all of them on success, or those that couldn't be
+// deleted after a failure.
+func applyConsentAdminRules(w io.Writer, projectID, location, datasetID, consentStoreID string, consents []consentRequest, mappings []userDataMappingRequest) ([]string, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	consentStoresService := healthcareService.Projects.Locations.Datasets.ConsentStores
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	// Sort the attribute IDs so that the requests don't depend on map order.
+	attributes := func(values map[string]string) []*healthcare.Attribute {
+		var ids []string
+		for id := range values {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		var attrs []*healthcare.Attribute
+		for _, id := range ids {
+			attrs = append(attrs, &healthcare.Attribute{
+				AttributeDefinitionId: id,
+				Values:                []string{values[id]},
+			})
+		}
+		return attrs
+	}
+
+	var created []createdResource
+	applyErr := func() error {
+		for i, c := range consents {
+			consent := &healthcare.Consent{
+				UserId:          c.UserID,
+				ConsentArtifact: c.ConsentArtifactName,
+				State:           c.State,
+				Policies: []*healthcare.GoogleCloudHealthcareV1beta1ConsentPolicy{
+					{
+						ResourceAttributes: attributes(c.ResourceAttributes),
+						AuthorizationRule: &healthcare.Expr{
+							Expression: c.AuthorizationRule,
+						},
+					},
+				},
+			}
+			resp, err := consentStoresService.Consents.Create(parent, consent).Do()
+			if err != nil {
+				return fmt.Errorf("consent %d: Create: %v", i, err)
+			}
+			fmt.Fprintf(w, "Created consent: %q (state: %s)\n", resp.Name, resp.State)
+			created = append(created, createdResource{name: resp.Name})
+		}
+		for i, m := range mappings {
+			mapping := &healthcare.UserDataMapping{
+				DataId:             m.DataID,
+				UserId:             m.UserID,
+				ResourceAttributes: attributes(m.ResourceAttributes),
+			}
+			resp, err := consentStoresService.UserDataMappings.Create(parent, mapping).Do()
+			if err != nil {
+				return fmt.Errorf("user data mapping %d: Create: %v", i, err)
+			}
+			fmt.Fprintf(w, "Created user data mapping: %q (data: %s, user: %s)\n", resp.Name, resp.DataId, resp.UserId)
+			created = append(created, createdResource{name: resp.Name, mapping: true})
+		}
+		return nil
+	}()
+	if applyErr == nil {
+		fmt.Fprintf(w, "Applied %d consent(s) and %d user data mapping(s)\n", len(consents), len(mappings))
+		names := make([]string, len(created))
+		for i, r := range created {
+			names[i] = r.name
+		}
+		return names, nil
+	}
+
+	// Roll back in reverse order, but report what remains in creation order.
+	var remaining, errs []string
+	for i := len(created) - 1; i >= 0; i-- {
+		name := created[i].name
+		var err error
+		if created[i].mapping {
+			_, err = consentStoresService.UserDataMappings.Delete(name).Do()
+		} else {
+			_, err = consentStoresService.Consents.Delete(name).Do()
+		}
+		if err != nil {
+			remaining = append([]string{name}, remaining...)
+			errs = append(errs, fmt.Sprintf("Delete %q: %v", name, err))
+			continue
+		}
+		fmt.Fprintf(w, "Rolled back %q\n", name)
+	}
+	if len(errs) > 0 {
+		return remaining, fmt.Errorf("%v; rollback: %s", applyErr, strings.Join(errs, "; "))
+	}
+	return nil, applyErr
+}
+
+// [END healthcare_apply_consent_admin_rules]
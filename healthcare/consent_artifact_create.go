@@ -0,0 +1,55 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_create_consent_artifact]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// createConsentArtifact records the proof of a user's consent, such as the
+// version of the consent form they agreed to, and returns the name of the
+// created artifact.
+func createConsentArtifact(w io.Writer, projectID, location, datasetID, consentStoreID, userID, consentContentVersion string) (string, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return "", fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	artifactsService := healthcareService.Projects.Locations.Datasets.ConsentStores.ConsentArtifacts
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	artifact := &healthcare.ConsentArtifact{
+		UserId:                userID,
+		ConsentContentVersion: consentContentVersion,
+	}
+
+	resp, err := artifactsService.Create(parent, artifact).Do()
+	if err != nil {
+		return "", fmt.Errorf("Create: %v", err)
+	}
+
+	fmt.Fprintf(w, "Created consent artifact: %q\n", resp.Name)
+	return resp.Name, nil
+}
+
+// [END healthcare_create_consent_artifact]
@@ -0,0 +1,58 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_create_attribute_definition]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// createAttributeDefinition creates an attribute definition in a consent
+// store. category must be either "RESOURCE" or "REQUEST".
+func createAttributeDefinition(w io.Writer, projectID, location, datasetID, consentStoreID, attributeDefinitionID, category string, allowedValues []string) error {
+	if category != "RESOURCE" && category != "REQUEST" {
+		return fmt.Errorf("invalid category %q: must be RESOURCE or REQUEST", category)
+	}
+
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	attributesService := healthcareService.Projects.Locations.Datasets.ConsentStores.AttributeDefinitions
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	definition := &healthcare.AttributeDefinition{
+		Category:      category,
+		AllowedValues: allowedValues,
+	}
+
+	resp, err := attributesService.Create(parent, definition).AttributeDefinitionId(attributeDefinitionID).Do()
+	if err != nil {
+		return fmt.Errorf("Create: %v", err)
+	}
+
+	fmt.Fprintf(w, "Created attribute definition: %q (category: %s, allowed values: %v)\n", resp.Name, resp.Category, resp.AllowedValues)
+	return nil
+}
+
+// [END healthcare_create_attribute_definition]
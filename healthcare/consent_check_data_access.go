@@ -0,0 +1,76 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_check_data_access]
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// checkDataAccess checks whether the data identified by dataID may be
+// accessed by a request with the given REQUEST attributes, and returns the
+// names of the consents that grant access.
+//
+// CheckDataAccess has no field for RESOURCE attributes: the API looks them
+// up from the user data mapping registered for dataID, so the data is named
+// by its ID rather than described by a resourceAttributes map. Each call
+// covers a single resource, so the result is the consents that grant access
+// to it rather than a list of resources. To evaluate every resource of a
+// user against resource attributes, use EvaluateUserConsents instead.
+func checkDataAccess(w io.Writer, projectID, location, datasetID, consentStoreID, dataID string, requestAttributes map[string]string) ([]string, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.ConsentStores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	req := &healthcare.CheckDataAccessRequest{
+		DataId:            dataID,
+		RequestAttributes: requestAttributes,
+		ResponseView:      "FULL",
+	}
+
+	resp, err := storesService.CheckDataAccess(name, req).Do()
+	if err != nil {
+		return nil, fmt.Errorf("CheckDataAccess: %v", err)
+	}
+
+	var consented []string
+	for consentName, evaluation := range resp.ConsentDetails {
+		if evaluation.EvaluationResult == "HAS_SATISFIED_POLICY" {
+			consented = append(consented, consentName)
+		}
+	}
+	sort.Strings(consented)
+
+	if resp.Consented {
+		fmt.Fprintf(w, "Access to %q granted by %d consent(s): %v\n", dataID, len(consented), consented)
+	} else {
+		fmt.Fprintf(w, "Access to %q denied\n", dataID)
+	}
+	return consented, nil
+}
+
+// [END healthcare_check_data_access]
@@ -0,0 +1,88 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_create_consent]
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// createConsent creates a consent for userID in the given state (for example,
+// "ACTIVE" or "DRAFT") and returns the name of the created consent.
+//
+// consentArtifactName is the full resource name of an existing consent
+// artifact. resourceAttributes maps RESOURCE attribute definition IDs to the
+// value the consent applies to, and authorizationRule is a CEL expression over
+// REQUEST attributes, such as `requesterIdentity == "external-researcher"`.
+func createConsent(w io.Writer, projectID, location, datasetID, consentStoreID, userID, consentArtifactName, state string, resourceAttributes map[string]string, authorizationRule string) (string, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return "", fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	consentsService := healthcareService.Projects.Locations.Datasets.ConsentStores.Consents
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	// Sort the attribute IDs so that the request doesn't depend on map order.
+	var ids []string
+	for id := range resourceAttributes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var attributes []*healthcare.Attribute
+	for _, id := range ids {
+		attributes = append(attributes, &healthcare.Attribute{
+			AttributeDefinitionId: id,
+			Values:                []string{resourceAttributes[id]},
+		})
+	}
+
+	consent := &healthcare.Consent{
+		UserId:          userID,
+		ConsentArtifact: consentArtifactName,
+		State:           state,
+		Policies: []*healthcare.GoogleCloudHealthcareV1beta1ConsentPolicy{
+			{
+				ResourceAttributes: attributes,
+				AuthorizationRule: &healthcare.Expr{
+					Expression: authorizationRule,
+				},
+			},
+		},
+	}
+
+	resp, err := consentsService.Create(parent, consent).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusBadRequest {
+			return "", fmt.Errorf("Create: invalid consent; check that consent artifact %q exists: %v", consentArtifactName, err)
+		}
+		return "", fmt.Errorf("Create: %v", err)
+	}
+
+	fmt.Fprintf(w, "Created consent: %q (state: %s)\n", resp.Name, resp.State)
+	return resp.Name, nil
+}
+
+// [END healthcare_create_consent]
@@ -0,0 +1,69 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_evaluate_user_consents]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// evaluateUserConsents evaluates the consents of userID against every piece of
+// data mapped to that user, for a request with the given REQUEST attributes.
+// It returns the data IDs the user has consented to.
+func evaluateUserConsents(w io.Writer, projectID, location, datasetID, consentStoreID, userID string, requestAttributes map[string]string) ([]string, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.ConsentStores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	req := &healthcare.EvaluateUserConsentsRequest{
+		UserId:            userID,
+		RequestAttributes: requestAttributes,
+		ResponseView:      "BASIC",
+	}
+
+	var consented []string
+	var evaluated int
+	err = storesService.EvaluateUserConsents(name, req).Pages(ctx, func(resp *healthcare.EvaluateUserConsentsResponse) error {
+		for _, result := range resp.Results {
+			evaluated++
+			if result.Consented {
+				consented = append(consented, result.DataId)
+				fmt.Fprintf(w, "%s: CONSENTED\n", result.DataId)
+			} else {
+				fmt.Fprintf(w, "%s: NOT_CONSENTED\n", result.DataId)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("EvaluateUserConsents: %v", err)
+	}
+
+	fmt.Fprintf(w, "User %q consented to %d of %d data items\n", userID, len(consented), evaluated)
+	return consented, nil
+}
+
+// [END healthcare_evaluate_user_consents]
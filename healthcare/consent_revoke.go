@@ -0,0 +1,59 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_revoke_consent]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// revokeConsent revokes a consent so that it no longer grants access.
+// Revoking a consent that is already revoked does nothing.
+func revokeConsent(w io.Writer, projectID, location, datasetID, consentStoreID, consentID string) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	consentsService := healthcareService.Projects.Locations.Datasets.ConsentStores.Consents
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s/consents/%s", projectID, location, datasetID, consentStoreID, consentID)
+
+	// The API rejects revoking a revoked consent, so check the state first.
+	consent, err := consentsService.Get(name).Do()
+	if err != nil {
+		return fmt.Errorf("Get: %v", err)
+	}
+	if consent.State == "REVOKED" {
+		fmt.Fprintf(w, "Consent %q is already revoked\n", name)
+		return nil
+	}
+
+	resp, err := consentsService.Revoke(name, &healthcare.RevokeConsentRequest{}).Do()
+	if err != nil {
+		return fmt.Errorf("Revoke: %v", err)
+	}
+
+	fmt.Fprintf(w, "Revoked consent: %q (state: %s)\n", resp.Name, resp.State)
+	return nil
+}
+
+// [END healthcare_revoke_consent]
@@ -0,0 +1,61 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_create_consent_store]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
+)
+
+// createConsentStore creates a consent store.
+// defaultConsentTTL is a duration such as "86400s"; leave it empty for
+// consents that never expire. enableConsentCreateOnUpdate allows updating a
+// consent that doesn't exist yet to create it.
+func createConsentStore(w io.Writer, projectID, location, datasetID, consentStoreID, defaultConsentTTL string, enableConsentCreateOnUpdate bool) error {
+	return createConsentStoreWithContext(context.Background(), w, projectID, location, datasetID, consentStoreID, defaultConsentTTL, enableConsentCreateOnUpdate)
+}
+
+// createConsentStoreWithContext is like createConsentStore but uses ctx for its
+// API calls. opts configure the service, for example its credentials or
+// endpoint.
+func createConsentStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, consentStoreID, defaultConsentTTL string, enableConsentCreateOnUpdate bool, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.ConsentStores
+
+	store := &healthcare.ConsentStore{
+		DefaultConsentTtl:           defaultConsentTTL,
+		EnableConsentCreateOnUpdate: enableConsentCreateOnUpdate,
+	}
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
+
+	resp, err := storesService.Create(parent, store).ConsentStoreId(consentStoreID).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Create: %v", err)
+	}
+
+	fmt.Fprintf(w, "Created consent store: %q (default consent TTL: %q, create on update: %t)\n", resp.Name, resp.DefaultConsentTtl, resp.EnableConsentCreateOnUpdate)
+	return nil
+}
+
+// [END healthcare_create_consent_store]
@@ -0,0 +1,60 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_delete_consent_store]
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
+)
+
+// deleteConsentStore deletes a consent store. Deleting a consent store that
+// doesn't exist is not an error.
+func deleteConsentStore(w io.Writer, projectID, location, datasetID, consentStoreID string) error {
+	return deleteConsentStoreWithContext(context.Background(), w, projectID, location, datasetID, consentStoreID)
+}
+
+// deleteConsentStoreWithContext is like deleteConsentStore but uses ctx for its
+// API calls. opts configure the service, for example its credentials or
+// endpoint.
+func deleteConsentStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, consentStoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.ConsentStores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	if _, err := storesService.Delete(name).Context(ctx).Do(); err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			fmt.Fprintf(w, "Consent store %q not found; nothing to delete\n", consentStoreID)
+			return nil
+		}
+		return fmt.Errorf("Delete: %v", err)
+	}
+
+	fmt.Fprintf(w, "Deleted consent store: %q\n", consentStoreID)
+	return nil
+}
+
+// [END healthcare_delete_consent_store]
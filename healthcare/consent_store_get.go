@@ -0,0 +1,58 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_get_consent_store]
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
+)
+
+// getConsentStore gets a consent store.
+func getConsentStore(w io.Writer, projectID, location, datasetID, consentStoreID string) error {
+	return getConsentStoreWithContext(context.Background(), w, projectID, location, datasetID, consentStoreID)
+}
+
+// getConsentStoreWithContext is like getConsentStore but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func getConsentStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, consentStoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.ConsentStores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	store, err := storesService.Get(name).Context(ctx).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return fmt.Errorf("Get: consent store %q not found: %v", name, err)
+		}
+		return fmt.Errorf("Get: %v", err)
+	}
+
+	fmt.Fprintf(w, "Got consent store: %q\n", store.Name)
+	return nil
+}
+
+// [END healthcare_get_consent_store]
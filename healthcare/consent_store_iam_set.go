@@ -0,0 +1,65 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_consent_store_set_iam_policy]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// setConsentStoreIamPolicy grants role to member on a consent store. The
+// member is not added again if it already has the role.
+func setConsentStoreIamPolicy(w io.Writer, projectID, location, datasetID, consentStoreID, role, member string) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.ConsentStores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	policy, err := storesService.GetIamPolicy(name).Do()
+	if err != nil {
+		return fmt.Errorf("GetIamPolicy: %v", err)
+	}
+
+	if !addIamBinding(policy, role, member) {
+		fmt.Fprintf(w, "%s already has %s on consent store %q\n", member, role, name)
+		return nil
+	}
+
+	req := &healthcare.SetIamPolicyRequest{
+		Policy: policy,
+	}
+
+	resp, err := storesService.SetIamPolicy(name, req).Do()
+	if err != nil {
+		return fmt.Errorf("SetIamPolicy: %v", err)
+	}
+
+	policyJSON, _ := json.MarshalIndent(resp, "", "  ")
+	fmt.Fprintf(w, "Set IAM policy for consent store %q:\n%s\n", name, policyJSON)
+	return nil
+}
+
+// [END healthcare_consent_store_set_iam_policy]
@@ -0,0 +1,63 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_list_consent_stores]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
+)
+
+// listConsentStores prints a list of consent stores to w.
+func listConsentStores(w io.Writer, projectID, location, datasetID string) error {
+	return listConsentStoresWithContext(context.Background(), w, projectID, location, datasetID)
+}
+
+// listConsentStoresWithContext is like listConsentStores but uses ctx for its
+// API calls. opts configure the service, for example its credentials or
+// endpoint.
+func listConsentStoresWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.ConsentStores
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
+
+	fmt.Fprintln(w, "Consent stores:")
+	count := 0
+	err = storesService.List(parent).Pages(ctx, func(resp *healthcare.ListConsentStoresResponse) error {
+		for _, s := range resp.ConsentStores {
+			fmt.Fprintln(w, s.Name)
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("List: %v", err)
+	}
+	if count == 0 {
+		fmt.Fprintln(w, "No consent stores found.")
+	}
+	return nil
+}
+
+// [END healthcare_list_consent_stores]
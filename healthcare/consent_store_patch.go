@@ -0,0 +1,61 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_patch_consent_store]
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
+)
+
+// patchConsentStore updates (patches) a consent store by replacing its labels.
+func patchConsentStore(w io.Writer, projectID, location, datasetID, consentStoreID string, labels map[string]string) error {
+	return patchConsentStoreWithContext(context.Background(), w, projectID, location, datasetID, consentStoreID, labels)
+}
+
+// patchConsentStoreWithContext is like patchConsentStore but uses ctx for its
+// API calls. opts configure the service, for example its credentials or
+// endpoint.
+func patchConsentStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, consentStoreID string, labels map[string]string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.ConsentStores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	resp, err := storesService.Patch(name, &healthcare.ConsentStore{
+		Labels: labels,
+	}).UpdateMask("labels").Context(ctx).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return fmt.Errorf("Patch: consent store %q not found: %v", name, err)
+		}
+		return fmt.Errorf("Patch: %v", err)
+	}
+
+	fmt.Fprintf(w, "Patched consent store %q with labels %v\n", resp.Name, resp.Labels)
+	return nil
+}
+
+// [END healthcare_patch_consent_store]
@@ -0,0 +1,252 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/golang-samples/internal/testutil"
+)
+
+// TestConsentStore runs all consent store tests to avoid having to
+// create/delete consent stores for every sample function that needs to be
+// tested.
+func TestConsentStore(t *testing.T) {
+	tc := testutil.SystemTest(t)
+	buf := &bytes.Buffer{}
+	location := "us-central1"
+	datasetID := "consent-dataset"
+	consentStoreID := "my-consent-store"
+	if err := createDataset(ioutil.Discard, tc.ProjectID, location, datasetID); err != nil {
+		t.Skipf("Unable to create test dataset: %v", err)
+		return
+	}
+
+	if err := createConsentStore(buf, tc.ProjectID, location, datasetID, consentStoreID, "86400s", true); err != nil {
+		t.Errorf("createConsentStore got err: %v", err)
+	}
+	if got, wantContain := buf.String(), consentStoreID; !strings.Contains(got, wantContain) {
+		t.Errorf("createConsentStore got\n----\n%v\n----\nWant to contain:\n----\n%v\n----\n", got, wantContain)
+	}
+
+	consentStoreName := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", tc.ProjectID, location, datasetID, consentStoreID)
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := listConsentStores(buf, tc.ProjectID, location, datasetID); err != nil {
+			r.Errorf("listConsentStores got err: %v", err)
+		}
+		if got := buf.String(); !strings.Contains(got, consentStoreName) {
+			r.Errorf("listConsentStores got\n----\n%v\n----\nWant to contain:\n----\n%v\n----\n", got, consentStoreName)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := getConsentStore(buf, tc.ProjectID, location, datasetID, consentStoreID); err != nil {
+			r.Errorf("getConsentStore got err: %v", err)
+		}
+		if got := buf.String(); !strings.Contains(got, consentStoreName) {
+			r.Errorf("getConsentStore got %q; want to contain %q", got, consentStoreName)
+		}
+	})
+
+	member := "group:dpebot@google.com"
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := setConsentStoreIamPolicy(buf, tc.ProjectID, location, datasetID, consentStoreID, "roles/viewer", member); err != nil {
+			r.Errorf("setConsentStoreIamPolicy got err: %v", err)
+		}
+		if got := buf.String(); !strings.Contains(got, member) {
+			r.Errorf("setConsentStoreIamPolicy got %q; want to contain %q", got, member)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := patchConsentStore(ioutil.Discard, tc.ProjectID, location, datasetID, consentStoreID, map[string]string{"env": "test"}); err != nil {
+			r.Errorf("patchConsentStore got err: %v", err)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := createAttributeDefinition(ioutil.Discard, tc.ProjectID, location, datasetID, consentStoreID, "data_identifiable", "RESOURCE", []string{"identifiable", "de-identified"}); err != nil {
+			r.Errorf("createAttributeDefinition got err: %v", err)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := createAttributeDefinition(ioutil.Discard, tc.ProjectID, location, datasetID, consentStoreID, "requesterIdentity", "REQUEST", []string{"internal-researcher", "external-researcher"}); err != nil {
+			r.Errorf("createAttributeDefinition got err: %v", err)
+		}
+	})
+
+	userID := "user-1"
+	var artifactName string
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		var err error
+		if artifactName, err = createConsentArtifact(ioutil.Discard, tc.ProjectID, location, datasetID, consentStoreID, userID, "v1"); err != nil {
+			r.Errorf("createConsentArtifact got err: %v", err)
+		}
+	})
+
+	var consentName string
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		var err error
+		consentName, err = createConsent(buf, tc.ProjectID, location, datasetID, consentStoreID, userID, artifactName, "ACTIVE", map[string]string{"data_identifiable": "de-identified"}, `requesterIdentity == "external-researcher"`)
+		if err != nil {
+			r.Errorf("createConsent got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "ACTIVE"; !strings.Contains(got, wantContain) {
+			r.Errorf("createConsent got %q; want to contain %q", got, wantContain)
+		}
+	})
+	if consentName == "" {
+		t.Errorf("createConsent returned empty consent name")
+	}
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		consented, err := checkDataAccess(buf, tc.ProjectID, location, datasetID, consentStoreID, "unmapped-data", map[string]string{"requesterIdentity": "external-researcher"})
+		if err != nil {
+			r.Errorf("checkDataAccess got err: %v", err)
+			return
+		}
+		if len(consented) != 0 {
+			r.Errorf("checkDataAccess got %d consents for unmapped data; want 0", len(consented))
+		}
+		if got, wantContain := buf.String(), "denied"; !strings.Contains(got, wantContain) {
+			r.Errorf("checkDataAccess got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if _, err := evaluateUserConsents(buf, tc.ProjectID, location, datasetID, consentStoreID, userID, map[string]string{"requesterIdentity": "external-researcher"}); err != nil {
+			r.Errorf("evaluateUserConsents got err: %v", err)
+			return
+		}
+		if got, wantContain := buf.String(), userID; !strings.Contains(got, wantContain) {
+			r.Errorf("evaluateUserConsents got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	var draftConsentName string
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		var err error
+		draftConsentName, err = createConsent(ioutil.Discard, tc.ProjectID, location, datasetID, consentStoreID, userID, artifactName, "DRAFT", map[string]string{"data_identifiable": "identifiable"}, `requesterIdentity == "internal-researcher"`)
+		if err != nil {
+			r.Errorf("createConsent (draft) got err: %v", err)
+		}
+	})
+	draftConsentID := path.Base(draftConsentName)
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := activateConsent(buf, tc.ProjectID, location, datasetID, consentStoreID, draftConsentID, artifactName, "86400s"); err != nil {
+			r.Errorf("activateConsent got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "ACTIVE"; !strings.Contains(got, wantContain) {
+			r.Errorf("activateConsent got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := revokeConsent(buf, tc.ProjectID, location, datasetID, consentStoreID, draftConsentID); err != nil {
+			r.Errorf("revokeConsent got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "REVOKED"; !strings.Contains(got, wantContain) {
+			r.Errorf("revokeConsent got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := revokeConsent(buf, tc.ProjectID, location, datasetID, consentStoreID, draftConsentID); err != nil {
+			r.Errorf("revokeConsent (already revoked) got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "already revoked"; !strings.Contains(got, wantContain) {
+			r.Errorf("revokeConsent (already revoked) got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	var mappingName string
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		var err error
+		mappingName, err = createUserDataMapping(ioutil.Discard, tc.ProjectID, location, datasetID, consentStoreID, "data-1", userID, map[string]string{"data_identifiable": "de-identified"})
+		if err != nil {
+			r.Errorf("createUserDataMapping got err: %v", err)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := listUserDataMappings(buf, tc.ProjectID, location, datasetID, consentStoreID); err != nil {
+			r.Errorf("listUserDataMappings got err: %v", err)
+		}
+		if got := buf.String(); !strings.Contains(got, mappingName) {
+			r.Errorf("listUserDataMappings got\n----\n%v\n----\nWant to contain:\n----\n%v\n----\n", got, mappingName)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := archiveUserDataMapping(ioutil.Discard, tc.ProjectID, location, datasetID, consentStoreID, path.Base(mappingName)); err != nil {
+			r.Errorf("archiveUserDataMapping got err: %v", err)
+		}
+	})
+
+	// The second mapping has no data ID, so applyConsentAdminRules fails and
+	// rolls back the consent and the first mapping.
+	names, err := applyConsentAdminRules(ioutil.Discard, tc.ProjectID, location, datasetID, consentStoreID,
+		[]consentRequest{{
+			UserID:              userID,
+			ConsentArtifactName: artifactName,
+			State:               "ACTIVE",
+			ResourceAttributes:  map[string]string{"data_identifiable": "de-identified"},
+			AuthorizationRule:   `requesterIdentity == "external-researcher"`,
+		}},
+		[]userDataMappingRequest{
+			{DataID: "data-2", UserID: userID, ResourceAttributes: map[string]string{"data_identifiable": "de-identified"}},
+			{UserID: userID},
+		})
+	if err == nil {
+		t.Errorf("applyConsentAdminRules with an invalid mapping got nil err, want error")
+	}
+	if len(names) != 0 {
+		t.Errorf("applyConsentAdminRules left %q after rollback, want none", names)
+	}
+
+	if err := createAttributeDefinition(ioutil.Discard, tc.ProjectID, location, datasetID, consentStoreID, "invalid", "PATIENT", []string{"a"}); err == nil {
+		t.Errorf("createAttributeDefinition with invalid category got nil err, want error")
+	}
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := deleteConsentStore(ioutil.Discard, tc.ProjectID, location, datasetID, consentStoreID); err != nil {
+			r.Errorf("deleteConsentStore got err: %v", err)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := deleteDataset(ioutil.Discard, tc.ProjectID, location, datasetID); err != nil {
+			r.Errorf("deleteDataset got err: %v", err)
+		}
+	})
+}
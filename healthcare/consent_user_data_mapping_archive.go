@@ -0,0 +1,48 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_archive_user_data_mapping]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// archiveUserDataMapping archives a user data mapping so that it is no longer
+// used when evaluating consents.
+func archiveUserDataMapping(w io.Writer, projectID, location, datasetID, consentStoreID, userDataMappingID string) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	mappingsService := healthcareService.Projects.Locations.Datasets.ConsentStores.UserDataMappings
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s/userDataMappings/%s", projectID, location, datasetID, consentStoreID, userDataMappingID)
+
+	if _, err := mappingsService.Archive(name, &healthcare.ArchiveUserDataMappingRequest{}).Do(); err != nil {
+		return fmt.Errorf("Archive: %v", err)
+	}
+
+	fmt.Fprintf(w, "Archived user data mapping: %q\n", name)
+	return nil
+}
+
+// [END healthcare_archive_user_data_mapping]
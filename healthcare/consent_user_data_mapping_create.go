@@ -0,0 +1,72 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_create_user_data_mapping]
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// createUserDataMapping maps the data identified by dataID to userID so that
+// the user's consents are evaluated for it, and returns the name of the
+// mapping. resourceAttributes maps RESOURCE attribute definition IDs to the
+// value that describes the data.
+func createUserDataMapping(w io.Writer, projectID, location, datasetID, consentStoreID, dataID, userID string, resourceAttributes map[string]string) (string, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return "", fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	mappingsService := healthcareService.Projects.Locations.Datasets.ConsentStores.UserDataMappings
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	// Sort the attribute IDs so that the request doesn't depend on map order.
+	var ids []string
+	for id := range resourceAttributes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var attributes []*healthcare.Attribute
+	for _, id := range ids {
+		attributes = append(attributes, &healthcare.Attribute{
+			AttributeDefinitionId: id,
+			Values:                []string{resourceAttributes[id]},
+		})
+	}
+
+	mapping := &healthcare.UserDataMapping{
+		DataId:             dataID,
+		UserId:             userID,
+		ResourceAttributes: attributes,
+	}
+
+	resp, err := mappingsService.Create(parent, mapping).Do()
+	if err != nil {
+		return "", fmt.Errorf("Create: %v", err)
+	}
+
+	fmt.Fprintf(w, "Created user data mapping: %q (data: %s, user: %s)\n", resp.Name, resp.DataId, resp.UserId)
+	return resp.Name, nil
+}
+
+// [END healthcare_create_user_data_mapping]
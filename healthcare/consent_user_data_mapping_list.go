@@ -0,0 +1,57 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_list_user_data_mappings]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// listUserDataMappings prints a list of user data mappings to w.
+func listUserDataMappings(w io.Writer, projectID, location, datasetID, consentStoreID string) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	mappingsService := healthcareService.Projects.Locations.Datasets.ConsentStores.UserDataMappings
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/consentStores/%s", projectID, location, datasetID, consentStoreID)
+
+	fmt.Fprintln(w, "User data mappings:")
+	count := 0
+	err = mappingsService.List(parent).Pages(ctx, func(resp *healthcare.ListUserDataMappingsResponse) error {
+		for _, m := range resp.UserDataMappings {
+			fmt.Fprintf(w, "%s (data: %s, user: %s)\n", m.Name, m.DataId, m.UserId)
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("List: %v", err)
+	}
+	if count == 0 {
+		fmt.Fprintln(w, "No user data mappings found.")
+	}
+	return nil
+}
+
+// [END healthcare_list_user_data_mappings]
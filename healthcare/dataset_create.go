@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // createDataset creates a dataset.
 func createDataset(w io.Writer, projectID, location, datasetID string) error {
-	ctx := context.Background()
+	return createDatasetWithContext(context.Background(), w, projectID, location, datasetID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// createDatasetWithContext is like createDataset but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func createDatasetWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -36,7 +41,7 @@ func createDataset(w io.Writer, projectID, location, datasetID string) error {
 
 	parent := fmt.Sprintf("projects/%s/locations/%s", projectID, location)
 
-	resp, err := datasetsService.Create(parent, &healthcare.Dataset{}).DatasetId(datasetID).Do()
+	resp, err := datasetsService.Create(parent, &healthcare.Dataset{}).DatasetId(datasetID).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("Create: %v", err)
 	}
@@ -0,0 +1,87 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_create_dataset_time_zone]
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// createDatasetWithTimeZone creates a dataset whose default time zone is
+// timeZone, an IANA name such as "America/New_York". The time zone is used to
+// interpret HL7V2 and FHIR timestamps that don't specify one.
+func createDatasetWithTimeZone(w io.Writer, projectID, location, datasetID, timeZone string) error {
+	return createDatasetWithTimeZoneWithContext(context.Background(), w, projectID, location, datasetID, timeZone)
+}
+
+// createDatasetWithTimeZoneWithContext is like createDatasetWithTimeZone but
+// uses ctx for its API calls, including polling the operation.
+func createDatasetWithTimeZoneWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, timeZone string) error {
+	if _, err := time.LoadLocation(timeZone); err != nil {
+		return fmt.Errorf("unknown time zone %q: %v", timeZone, err)
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	datasetsService := healthcareService.Projects.Locations.Datasets
+
+	parent := fmt.Sprintf("projects/%s/locations/%s", projectID, location)
+
+	lro, err := datasetsService.Create(parent, &healthcare.Dataset{TimeZone: timeZone}).DatasetId(datasetID).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Create: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("create: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("create: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+
+	name := fmt.Sprintf("%s/datasets/%s", parent, datasetID)
+	dataset, err := datasetsService.Get(name).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Get: %v", err)
+	}
+
+	fmt.Fprintf(w, "Created dataset: %q (time zone: %s)\n", dataset.Name, dataset.TimeZone)
+	return nil
+}
+
+// [END healthcare_create_dataset_time_zone]
@@ -21,13 +21,19 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // deleteDataset deletes the given dataset.
 func deleteDataset(w io.Writer, projectID, location, datasetID string) error {
-	ctx := context.Background()
+	return deleteDatasetWithContext(context.Background(), w, projectID, location, datasetID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// deleteDatasetWithContext is like deleteDataset but uses ctx for its API
+// calls, so the caller can bound how long they take or cancel them. opts
+// configure the service, for example its credentials or endpoint.
+func deleteDatasetWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -35,7 +41,7 @@ func deleteDataset(w io.Writer, projectID, location, datasetID string) error {
 	datasetsService := healthcareService.Projects.Locations.Datasets
 
 	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
-	if _, err := datasetsService.Delete(name).Do(); err != nil {
+	if _, err := datasetsService.Delete(name).Context(ctx).Do(); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
 
@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // getDataset gets a dataset.
 func getDataset(w io.Writer, projectID, location, datasetID string) error {
-	ctx := context.Background()
+	return getDatasetWithContext(context.Background(), w, projectID, location, datasetID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// getDatasetWithContext is like getDataset but uses ctx for its API calls. opts
+// configure the service, for example its credentials or endpoint.
+func getDatasetWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -36,7 +41,7 @@ func getDataset(w io.Writer, projectID, location, datasetID string) error {
 
 	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
 
-	resp, err := datasetsService.Get(name).Do()
+	resp, err := datasetsService.Get(name).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("Get: %v", err)
 	}
@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // listDatasets prints a list of datasets to w.
 func listDatasets(w io.Writer, projectID string, location string) error {
-	ctx := context.Background()
+	return listDatasetsWithContext(context.Background(), w, projectID, location)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// listDatasetsWithContext is like listDatasets but uses ctx for its API calls.
+// opts configure the service, for example its credentials or endpoint.
+func listDatasetsWithContext(ctx context.Context, w io.Writer, projectID string, location string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -36,7 +41,7 @@ func listDatasets(w io.Writer, projectID string, location string) error {
 
 	parent := fmt.Sprintf("projects/%s/locations/%s", projectID, location)
 
-	resp, err := datasetsService.List(parent).Do()
+	resp, err := datasetsService.List(parent).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("List: %v", err)
 	}
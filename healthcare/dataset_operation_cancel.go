@@ -0,0 +1,71 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_cancel_dataset_operation]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// cancelDatasetOperation requests cancellation of a long-running operation,
+// such as an import, export, or de-identification, started in a dataset.
+// Cancellation is best effort: the operation may still finish, and an
+// operation that has already finished is left as is.
+func cancelDatasetOperation(w io.Writer, projectID, location, datasetID, operationID string) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	operationsService := healthcareService.Projects.Locations.Datasets.Operations
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/operations/%s", projectID, location, datasetID, operationID)
+
+	op, err := operationsService.Get(name).Do()
+	if err != nil {
+		return fmt.Errorf("Get: %v", err)
+	}
+	if op.Done {
+		fmt.Fprintf(w, "Operation %q has already finished; nothing to cancel\n", op.Name)
+		return nil
+	}
+
+	if _, err := operationsService.Cancel(name, &healthcare.CancelOperationRequest{}).Do(); err != nil {
+		// The operation may have finished after it was fetched above.
+		if op, getErr := operationsService.Get(name).Do(); getErr == nil && op.Done {
+			fmt.Fprintf(w, "Operation %q finished before it could be cancelled\n", op.Name)
+			return nil
+		}
+		return fmt.Errorf("Cancel: %v", err)
+	}
+
+	op, err = operationsService.Get(name).Do()
+	if err != nil {
+		return fmt.Errorf("Get: %v", err)
+	}
+	fmt.Fprintf(w, "Requested cancellation of operation %q (done: %t)\n", op.Name, op.Done)
+	if op.Error != nil {
+		fmt.Fprintf(w, "Operation status: %s\n", op.Error.Message)
+	}
+	return nil
+}
+
+// [END healthcare_cancel_dataset_operation]
@@ -0,0 +1,65 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_list_dataset_operations]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// listDatasetOperations prints the long-running operations, such as imports,
+// exports, and de-identifications, started in a dataset. If inFlightOnly is
+// true, only operations that haven't finished are printed.
+func listDatasetOperations(w io.Writer, projectID, location, datasetID string, inFlightOnly bool) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	operationsService := healthcareService.Projects.Locations.Datasets.Operations
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
+
+	fmt.Fprintln(w, "Operations:")
+	count := 0
+	err = operationsService.List(name).Pages(ctx, func(resp *healthcare.ListOperationsResponse) error {
+		for _, op := range resp.Operations {
+			if inFlightOnly && op.Done {
+				continue
+			}
+			fmt.Fprintf(w, "%s (done: %t)\n", op.Name, op.Done)
+			if op.Error != nil {
+				fmt.Fprintf(w, "  error: %s\n", op.Error.Message)
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("List: %v", err)
+	}
+	if count == 0 {
+		fmt.Fprintln(w, "No operations found.")
+	}
+	return nil
+}
+
+// [END healthcare_list_dataset_operations]
@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // patchDataset updates (patches) a dataset by updating its timezone..
 func patchDataset(w io.Writer, projectID, location, datasetID, newTimeZone string) error {
-	ctx := context.Background()
+	return patchDatasetWithContext(context.Background(), w, projectID, location, datasetID, newTimeZone)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// patchDatasetWithContext is like patchDataset but uses ctx for its API calls.
+// opts configure the service, for example its credentials or endpoint.
+func patchDatasetWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, newTimeZone string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -38,7 +43,7 @@ func patchDataset(w io.Writer, projectID, location, datasetID, newTimeZone strin
 
 	if _, err := datasetsService.Patch(name, &healthcare.Dataset{
 		TimeZone: newTimeZone,
-	}).UpdateMask("timeZone").Do(); err != nil {
+	}).UpdateMask("timeZone").Context(ctx).Do(); err != nil {
 		return fmt.Errorf("Patch: %v", err)
 	}
 
@@ -33,6 +33,7 @@ func TestDataset(t *testing.T) {
 	location := "us-central1"
 	datasetID := "my-dataset"
 	deidentifiedDatasetID := "my-dataset-deidentified"
+	timeZoneDatasetID := "my-dataset-time-zone"
 	if err := createDataset(buf, tc.ProjectID, location, datasetID); err != nil {
 		t.Fatalf("createDataset got err: %v", err)
 	}
@@ -59,18 +60,70 @@ func TestDataset(t *testing.T) {
 		}
 	})
 
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := getDatasetIamPolicy(ioutil.Discard, tc.ProjectID, location, datasetID); err != nil {
+			r.Errorf("getDatasetIamPolicy got err: %v", err)
+		}
+	})
+
+	member := "group:dpebot@google.com"
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := setDatasetIamPolicy(buf, tc.ProjectID, location, datasetID, "roles/viewer", member); err != nil {
+			r.Errorf("setDatasetIamPolicy got err: %v", err)
+		}
+		if got := buf.String(); !strings.Contains(got, member) {
+			r.Errorf("setDatasetIamPolicy got %q; want to contain %q", got, member)
+		}
+	})
+
 	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
 		if err := deidentifyDataset(ioutil.Discard, tc.ProjectID, location, datasetID, deidentifiedDatasetID); err != nil {
 			r.Errorf("deidentifyDataset got err: %v", err)
 		}
 	})
 
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := listDatasetOperations(buf, tc.ProjectID, location, datasetID, false); err != nil {
+			r.Errorf("listDatasetOperations got err: %v", err)
+		}
+		// deidentifyDataset started an operation in the source dataset.
+		if got, wantContain := buf.String(), name+"/operations/"; !strings.Contains(got, wantContain) {
+			r.Errorf("listDatasetOperations got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	if err := cancelDatasetOperation(ioutil.Discard, tc.ProjectID, location, datasetID, "does-not-exist"); err == nil {
+		t.Errorf("cancelDatasetOperation on a missing operation got nil err, want error")
+	}
+
 	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
 		if err := patchDataset(ioutil.Discard, tc.ProjectID, location, datasetID, "UTC"); err != nil {
 			r.Errorf("patchDataset got err: %v", err)
 		}
 	})
 
+	if err := createDatasetWithTimeZone(ioutil.Discard, tc.ProjectID, location, timeZoneDatasetID, "Mars/Olympus_Mons"); err == nil {
+		t.Errorf("createDatasetWithTimeZone with an unknown time zone got nil err, want error")
+	}
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := createDatasetWithTimeZone(buf, tc.ProjectID, location, timeZoneDatasetID, "America/New_York"); err != nil {
+			r.Errorf("createDatasetWithTimeZone got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "America/New_York"; !strings.Contains(got, wantContain) {
+			r.Errorf("createDatasetWithTimeZone got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := deleteDataset(ioutil.Discard, tc.ProjectID, location, timeZoneDatasetID); err != nil {
+			r.Errorf("deleteDataset (time zone) got err: %v", err)
+		}
+	})
+
 	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
 		if err := deleteDataset(ioutil.Discard, tc.ProjectID, location, datasetID); err != nil {
 			r.Errorf("deleteDataset got err: %v", err)
@@ -0,0 +1,77 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import healthcare "google.golang.org/api/healthcare/v1beta1"
+
+// deidentifyOptions describes how data is de-identified. The zero value
+// leaves every kind of data untouched.
+type deidentifyOptions struct {
+	// DICOMKeepTags lists the DICOM tags to keep; all others are removed.
+	// It takes precedence over DICOMFilterProfile.
+	DICOMKeepTags []string
+	// DICOMFilterProfile selects a predefined set of DICOM tags to keep,
+	// such as "MINIMAL_KEEP_LIST_PROFILE".
+	DICOMFilterProfile string
+
+	// DeidentifyFHIR enables FHIR de-identification. Fields not listed in
+	// FHIRFields are de-identified using the default transformations.
+	DeidentifyFHIR bool
+	FHIRFields     []*healthcare.FieldMetadata
+
+	// TextInfoTypes lists the info types, such as "PERSON_NAME", that are
+	// redacted from free text.
+	TextInfoTypes []string
+
+	// ImageTextRedactionMode controls which text is redacted from images,
+	// such as "REDACT_ALL_TEXT".
+	ImageTextRedactionMode string
+}
+
+// buildDeidentifyConfig assembles a DeidentifyConfig from opts, leaving out
+// the configs for kinds of data opts doesn't mention.
+func buildDeidentifyConfig(opts deidentifyOptions) *healthcare.DeidentifyConfig {
+	config := &healthcare.DeidentifyConfig{}
+
+	switch {
+	case len(opts.DICOMKeepTags) > 0:
+		config.Dicom = &healthcare.DicomConfig{
+			KeepList: &healthcare.TagFilterList{Tags: opts.DICOMKeepTags},
+		}
+	case opts.DICOMFilterProfile != "":
+		config.Dicom = &healthcare.DicomConfig{FilterProfile: opts.DICOMFilterProfile}
+	}
+
+	if opts.DeidentifyFHIR || len(opts.FHIRFields) > 0 {
+		config.Fhir = &healthcare.FhirConfig{FieldMetadataList: opts.FHIRFields}
+	}
+
+	if len(opts.TextInfoTypes) > 0 {
+		config.Text = &healthcare.TextConfig{
+			Transformations: []*healthcare.InfoTypeTransformation{
+				{
+					InfoTypes:    opts.TextInfoTypes,
+					RedactConfig: &healthcare.RedactConfig{},
+				},
+			},
+		}
+	}
+
+	if opts.ImageTextRedactionMode != "" {
+		config.Image = &healthcare.ImageConfig{TextRedactionMode: opts.ImageTextRedactionMode}
+	}
+
+	return config
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import (
+	"reflect"
+	"testing"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+func TestBuildDeidentifyConfig(t *testing.T) {
+	fields := []*healthcare.FieldMetadata{
+		{Paths: []string{"Patient.birthDate"}, Action: "DO_NOT_TRANSFORM"},
+	}
+	opts := deidentifyOptions{
+		DICOMKeepTags:          []string{"PatientID", "StudyInstanceUID"},
+		DICOMFilterProfile:     "MINIMAL_KEEP_LIST_PROFILE",
+		FHIRFields:             fields,
+		TextInfoTypes:          []string{"PERSON_NAME"},
+		ImageTextRedactionMode: "REDACT_ALL_TEXT",
+	}
+	want := &healthcare.DeidentifyConfig{
+		Dicom: &healthcare.DicomConfig{
+			KeepList: &healthcare.TagFilterList{Tags: []string{"PatientID", "StudyInstanceUID"}},
+		},
+		Fhir: &healthcare.FhirConfig{FieldMetadataList: fields},
+		Text: &healthcare.TextConfig{
+			Transformations: []*healthcare.InfoTypeTransformation{
+				{InfoTypes: []string{"PERSON_NAME"}, RedactConfig: &healthcare.RedactConfig{}},
+			},
+		},
+		Image: &healthcare.ImageConfig{TextRedactionMode: "REDACT_ALL_TEXT"},
+	}
+	if got := buildDeidentifyConfig(opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("buildDeidentifyConfig(%+v) = %+v, want %+v", opts, got, want)
+	}
+
+	if got := buildDeidentifyConfig(deidentifyOptions{}); !reflect.DeepEqual(got, &healthcare.DeidentifyConfig{}) {
+		t.Errorf("buildDeidentifyConfig with zero options = %+v, want an empty config", got)
+	}
+
+	got := buildDeidentifyConfig(deidentifyOptions{DICOMFilterProfile: "MINIMAL_KEEP_LIST_PROFILE", DeidentifyFHIR: true})
+	if got.Dicom == nil || got.Dicom.FilterProfile != "MINIMAL_KEEP_LIST_PROFILE" || got.Dicom.KeepList != nil {
+		t.Errorf("buildDeidentifyConfig with a filter profile got Dicom %+v, want only FilterProfile set", got.Dicom)
+	}
+	if got.Fhir == nil {
+		t.Errorf("buildDeidentifyConfig with DeidentifyFHIR got nil Fhir config")
+	}
+}
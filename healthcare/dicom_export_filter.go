@@ -0,0 +1,126 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_export_dicom_instances_filter]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// exportDICOMInstancesWithFilter exports a subset of the DICOM objects in a
+// store to destination, which has the form "gs://my-bucket/path/to/prefix/".
+//
+// Exactly one of filterFileURI and studyUIDs must be set. filterFileURI names
+// a GCS file listing one resource path per line, such as "/studies/1.2.3" or
+// "/studies/1.2.3/series/4.5.6". If studyUIDs is set instead, a filter file
+// selecting those studies is written to destination + "filter.txt".
+func exportDICOMInstancesWithFilter(w io.Writer, projectID, location, datasetID, dicomStoreID, destination, filterFileURI string, studyUIDs []string) error {
+	return exportDICOMInstancesWithFilterWithContext(context.Background(), w, projectID, location, datasetID, dicomStoreID, destination, filterFileURI, studyUIDs)
+}
+
+// exportDICOMInstancesWithFilterWithContext is like
+// exportDICOMInstancesWithFilter but uses ctx for its API calls, including
+// polling the operation.
+func exportDICOMInstancesWithFilterWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, dicomStoreID, destination, filterFileURI string, studyUIDs []string) error {
+	if (filterFileURI == "") == (len(studyUIDs) == 0) {
+		return fmt.Errorf("exactly one of filterFileURI and studyUIDs must be set")
+	}
+
+	if len(studyUIDs) > 0 {
+		filterFileURI = strings.TrimSuffix(destination, "/") + "/filter.txt"
+		parts := strings.SplitN(strings.TrimPrefix(filterFileURI, "gs://"), "/", 2)
+		if !strings.HasPrefix(filterFileURI, "gs://") || len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("invalid destination %q: must have the form gs://bucket/path/to/prefix/", destination)
+		}
+		bucket, object := parts[0], parts[1]
+
+		storageClient, err := storage.NewClient(ctx)
+		if err != nil {
+			return fmt.Errorf("storage.NewClient: %v", err)
+		}
+		defer storageClient.Close()
+
+		wc := storageClient.Bucket(bucket).Object(object).NewWriter(ctx)
+		for _, uid := range studyUIDs {
+			fmt.Fprintf(wc, "/studies/%s\n", uid)
+		}
+		if err := wc.Close(); err != nil {
+			return fmt.Errorf("could not write filter file %q: %v", filterFileURI, err)
+		}
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.DicomStores
+
+	req := &healthcare.ExportDicomDataRequest{
+		GcsDestination: &healthcare.GoogleCloudHealthcareV1beta1DicomGcsDestination{
+			UriPrefix: destination,
+		},
+		FilterConfig: &healthcare.DicomFilterConfig{
+			ResourcePathsGcsUri: filterFileURI,
+		},
+	}
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+
+	lro, err := storesService.Export(name, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Export: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("export: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("export: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+	var metadata healthcare.OperationMetadata
+	if err := json.Unmarshal(op.Metadata, &metadata); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+	if metadata.Counter == nil {
+		metadata.Counter = &healthcare.ProgressCounter{}
+	}
+	fmt.Fprintf(w, "Exported DICOM instances matching %s to %s (succeeded: %d, failed: %d)\n", filterFileURI, destination, metadata.Counter.Success, metadata.Counter.Failure)
+	return nil
+}
+
+// [END healthcare_export_dicom_instances_filter]
@@ -0,0 +1,147 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_import_dicom_instances_filter]
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// importDICOMInstancesWithFilter imports the DICOM objects listed in a filter
+// file in GCS. filterFileURI has the form "gs://my-bucket/filter.txt", and the
+// file contains one object URI or wildcard per line, such as
+// "gs://my-bucket/study-1/*.dcm". It returns the progress counters of each
+// import, keyed by the line it came from.
+//
+// The import API has no filter config: it takes a single source URI. So this
+// starts one import per line, then waits for all of them. If an import can't
+// be started or fails, the other imports still run, leaving the store with a
+// partial import.
+func importDICOMInstancesWithFilter(w io.Writer, projectID, location, datasetID, dicomStoreID, filterFileURI string) (map[string]*healthcare.ProgressCounter, error) {
+	return importDICOMInstancesWithFilterWithContext(context.Background(), w, projectID, location, datasetID, dicomStoreID, filterFileURI)
+}
+
+// importDICOMInstancesWithFilterWithContext is like
+// importDICOMInstancesWithFilter but uses ctx for its API calls, including
+// polling the operations.
+func importDICOMInstancesWithFilterWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, dicomStoreID, filterFileURI string) (map[string]*healthcare.ProgressCounter, error) {
+	parts := strings.SplitN(strings.TrimPrefix(filterFileURI, "gs://"), "/", 2)
+	if !strings.HasPrefix(filterFileURI, "gs://") || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid filter file URI %q: must have the form gs://bucket/object", filterFileURI)
+	}
+
+	storageClient, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewClient: %v", err)
+	}
+	defer storageClient.Close()
+
+	rc, err := storageClient.Bucket(parts[0]).Object(parts[1]).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("NewReader(%q): %v", filterFileURI, err)
+	}
+	defer rc.Close()
+
+	var sources []string
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			sources = append(sources, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read filter file %q: %v", filterFileURI, err)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("filter file %q lists no objects", filterFileURI)
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.DicomStores
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+
+	// Start every import before waiting on any, so that they run in parallel.
+	lros := make([]*healthcare.Operation, len(sources))
+	var failures []string
+	for i, source := range sources {
+		req := &healthcare.ImportDicomDataRequest{
+			GcsSource: &healthcare.GoogleCloudHealthcareV1beta1DicomGcsSource{
+				Uri: source,
+			},
+		}
+		if lros[i], err = storesService.Import(name, req).Context(ctx).Do(); err != nil {
+			failures = append(failures, fmt.Sprintf("Import(%q): %v", source, err))
+		}
+	}
+
+	// Poll the operations until they are done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	counters := make(map[string]*healthcare.ProgressCounter)
+	for i, lro := range lros {
+		if lro == nil {
+			continue
+		}
+		source := sources[i]
+		op := lro
+		for delay := time.Second; !op.Done; {
+			select {
+			case <-ctx.Done():
+				return counters, fmt.Errorf("import from %q: operation %q did not finish: %v", source, lro.Name, ctx.Err())
+			case <-time.After(delay):
+			}
+			if delay *= 2; delay > 30*time.Second {
+				delay = 30 * time.Second
+			}
+			if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+				return counters, fmt.Errorf("Operations.Get: %v", err)
+			}
+		}
+		if op.Error != nil {
+			failures = append(failures, fmt.Sprintf("import from %q: operation %q failed: %s", source, op.Name, op.Error.Message))
+			continue
+		}
+		var metadata healthcare.OperationMetadata
+		if err := json.Unmarshal(op.Metadata, &metadata); err != nil {
+			return counters, fmt.Errorf("json.Unmarshal: %v", err)
+		}
+		if metadata.Counter == nil {
+			metadata.Counter = &healthcare.ProgressCounter{}
+		}
+		counters[source] = metadata.Counter
+		fmt.Fprintf(w, "Imported %d instances from %s (%d failed)\n", metadata.Counter.Success, source, metadata.Counter.Failure)
+	}
+
+	if len(failures) > 0 {
+		return counters, fmt.Errorf("%d of %d imports failed: %s", len(failures), len(sources), strings.Join(failures, "; "))
+	}
+	return counters, nil
+}
+
+// [END healthcare_import_dicom_instances_filter]
@@ -0,0 +1,93 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_set_dicom_store_blob_storage_settings]
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// blobStorageClasses are the storage classes DICOM instances can be moved to.
+var blobStorageClasses = map[string]bool{
+	"STANDARD": true,
+	"NEARLINE": true,
+	"COLDLINE": true,
+	"ARCHIVE":  true,
+}
+
+// setDICOMStoreBlobStorageSettings moves every instance in a DICOM store to
+// storageClass, such as "ARCHIVE" for rarely accessed imaging.
+func setDICOMStoreBlobStorageSettings(w io.Writer, projectID, location, datasetID, dicomStoreID, storageClass string) error {
+	return setDICOMStoreBlobStorageSettingsWithContext(context.Background(), w, projectID, location, datasetID, dicomStoreID, storageClass)
+}
+
+// setDICOMStoreBlobStorageSettingsWithContext is like
+// setDICOMStoreBlobStorageSettings but uses ctx for its API calls, including
+// polling the operation.
+func setDICOMStoreBlobStorageSettingsWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, dicomStoreID, storageClass string) error {
+	if !blobStorageClasses[storageClass] {
+		return fmt.Errorf("invalid storage class %q: must be STANDARD, NEARLINE, COLDLINE or ARCHIVE", storageClass)
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.DicomStores
+
+	req := &healthcare.SetBlobStorageSettingsRequest{
+		BlobStorageSettings: &healthcare.BlobStorageSettings{
+			BlobStorageClass: storageClass,
+		},
+	}
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+
+	lro, err := storesService.SetBlobStorageSettings(name, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("SetBlobStorageSettings: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("set blob storage settings: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("set blob storage settings: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+	fmt.Fprintf(w, "Set storage class of DICOM store %s to %s\n", name, storageClass)
+	return nil
+}
+
+// [END healthcare_set_dicom_store_blob_storage_settings]
@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // createDICOMStore creates a DICOM store.
 func createDICOMStore(w io.Writer, projectID, location, datasetID, dicomStoreID string) error {
-	ctx := context.Background()
+	return createDICOMStoreWithContext(context.Background(), w, projectID, location, datasetID, dicomStoreID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// createDICOMStoreWithContext is like createDICOMStore but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func createDICOMStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, dicomStoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -37,7 +42,7 @@ func createDICOMStore(w io.Writer, projectID, location, datasetID, dicomStoreID
 	store := &healthcare.DicomStore{}
 	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
 
-	resp, err := storesService.Create(parent, store).DicomStoreId(dicomStoreID).Do()
+	resp, err := storesService.Create(parent, store).DicomStoreId(dicomStoreID).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("Create: %v", err)
 	}
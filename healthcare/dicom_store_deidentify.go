@@ -0,0 +1,87 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_deidentify_dicom_store]
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// deidentifyDICOMStore de-identifies the instances in a DICOM store and writes
+// them to the existing DICOM store destinationStoreName, which has the form
+// "projects/*/locations/*/datasets/*/dicomStores/*".
+//
+// filterProfile selects which tags are kept, for example
+// "MINIMAL_KEEP_LIST_PROFILE" or "ATTRIBUTE_CONFIDENTIALITY_BASIC_PROFILE".
+func deidentifyDICOMStore(w io.Writer, projectID, location, datasetID, sourceStoreID, destinationStoreName, filterProfile string) error {
+	return deidentifyDICOMStoreWithContext(context.Background(), w, projectID, location, datasetID, sourceStoreID, destinationStoreName, filterProfile)
+}
+
+// deidentifyDICOMStoreWithContext is like deidentifyDICOMStore but uses ctx for
+// its API calls, including polling the operation.
+func deidentifyDICOMStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, sourceStoreID, destinationStoreName, filterProfile string) error {
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.DicomStores
+
+	req := &healthcare.DeidentifyDicomStoreRequest{
+		DestinationStore: destinationStoreName,
+		Config: &healthcare.DeidentifyConfig{
+			Dicom: &healthcare.DicomConfig{
+				FilterProfile: filterProfile,
+			},
+		},
+	}
+
+	sourceName := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, sourceStoreID)
+	lro, err := storesService.Deidentify(sourceName, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Deidentify: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("deidentify: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("deidentify: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+	fmt.Fprintf(w, "De-identified DICOM store %s into %s\n", sourceName, destinationStoreName)
+	return nil
+}
+
+// [END healthcare_deidentify_dicom_store]
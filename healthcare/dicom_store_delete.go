@@ -21,13 +21,19 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // deleteDICOMStore deletes an DICOM store.
 func deleteDICOMStore(w io.Writer, projectID, location, datasetID, dicomStoreID string) error {
-	ctx := context.Background()
+	return deleteDICOMStoreWithContext(context.Background(), w, projectID, location, datasetID, dicomStoreID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// deleteDICOMStoreWithContext is like deleteDICOMStore but uses ctx for its API
+// calls, so the caller can bound how long they take or cancel them. opts
+// configure the service, for example its credentials or endpoint.
+func deleteDICOMStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, dicomStoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -35,7 +41,7 @@ func deleteDICOMStore(w io.Writer, projectID, location, datasetID, dicomStoreID
 	storesService := healthcareService.Projects.Locations.Datasets.DicomStores
 
 	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
-	if _, err := storesService.Delete(name).Do(); err != nil {
+	if _, err := storesService.Delete(name).Context(ctx).Do(); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
 
@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // getDICOMStore gets a DICOM store.
 func getDICOMStore(w io.Writer, projectID, location, datasetID, dicomStoreID string) error {
-	ctx := context.Background()
+	return getDICOMStoreWithContext(context.Background(), w, projectID, location, datasetID, dicomStoreID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// getDICOMStoreWithContext is like getDICOMStore but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func getDICOMStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, dicomStoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -36,7 +41,7 @@ func getDICOMStore(w io.Writer, projectID, location, datasetID, dicomStoreID str
 
 	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
 
-	store, err := storesService.Get(name).Do()
+	store, err := storesService.Get(name).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("Get: %v", err)
 	}
@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // listDICOMStores prints a list of DICOM stores to w.
 func listDICOMStores(w io.Writer, projectID, location, datasetID string) error {
-	ctx := context.Background()
+	return listDICOMStoresWithContext(context.Background(), w, projectID, location, datasetID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// listDICOMStoresWithContext is like listDICOMStores but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func listDICOMStoresWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -36,7 +41,7 @@ func listDICOMStores(w io.Writer, projectID, location, datasetID string) error {
 
 	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
 
-	resp, err := storesService.List(parent).Do()
+	resp, err := storesService.List(parent).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("List: %v", err)
 	}
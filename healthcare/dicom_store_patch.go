@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // patchDICOMStore updates (patches) a DICOM store by updating its Pub/sub topic name.
 func patchDICOMStore(w io.Writer, projectID, location, datasetID, dicomStoreID, topicName string) error {
-	ctx := context.Background()
+	return patchDICOMStoreWithContext(context.Background(), w, projectID, location, datasetID, dicomStoreID, topicName)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// patchDICOMStoreWithContext is like patchDICOMStore but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func patchDICOMStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, dicomStoreID, topicName string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -40,7 +45,7 @@ func patchDICOMStore(w io.Writer, projectID, location, datasetID, dicomStoreID,
 		NotificationConfig: &healthcare.NotificationConfig{
 			PubsubTopic: topicName, // format is "projects/*/locations/*/topics/*"
 		},
-	}).UpdateMask("notificationConfig").Do(); err != nil {
+	}).UpdateMask("notificationConfig").Context(ctx).Do(); err != nil {
 		return fmt.Errorf("Patch: %v", err)
 	}
 
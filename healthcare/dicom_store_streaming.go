@@ -0,0 +1,71 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_dicom_store_configure_streaming]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// bigQueryTableURIRE matches BigQuery table URIs of the form
+// "bq://PROJECT_ID.DATASET_ID.TABLE_ID".
+var bigQueryTableURIRE = regexp.MustCompile(`^bq://[a-z0-9:.-]+\.\w+\.\w+$`)
+
+// configureDICOMStoreStreaming updates (patches) a DICOM store to stream
+// instance metadata changes to the BigQuery table bigQueryTableURI, which
+// must have the form "bq://PROJECT_ID.DATASET_ID.TABLE_ID".
+func configureDICOMStoreStreaming(w io.Writer, projectID, location, datasetID, dicomStoreID, bigQueryTableURI string) error {
+	if !bigQueryTableURIRE.MatchString(bigQueryTableURI) {
+		return fmt.Errorf("invalid BigQuery table URI %q: want bq://PROJECT_ID.DATASET_ID.TABLE_ID", bigQueryTableURI)
+	}
+
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.DicomStores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+
+	store := &healthcare.DicomStore{
+		StreamConfigs: []*healthcare.GoogleCloudHealthcareV1beta1DicomStreamConfig{
+			{
+				BigqueryDestination: &healthcare.GoogleCloudHealthcareV1beta1DicomBigQueryDestination{
+					TableUri: bigQueryTableURI,
+				},
+			},
+		},
+	}
+
+	resp, err := storesService.Patch(name, store).UpdateMask("streamConfigs").Do()
+	if err != nil {
+		return fmt.Errorf("Patch: %v", err)
+	}
+
+	configJSON, _ := json.MarshalIndent(resp.StreamConfigs, "", "  ")
+	fmt.Fprintf(w, "Patched DICOM store %q to stream to BigQuery:\n%s\n", resp.Name, configJSON)
+	return nil
+}
+
+// [END healthcare_dicom_store_configure_streaming]
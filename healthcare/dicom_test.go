@@ -16,6 +16,8 @@ package snippets
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"strings"
@@ -55,6 +57,143 @@ func TestDICOMStore(t *testing.T) {
 
 	// TODO(cbro): test get
 
+	member := "group:dpebot@google.com"
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := getDICOMStoreIamPolicy(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID); err != nil {
+			r.Errorf("getDICOMStoreIamPolicy got err: %v", err)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := setDICOMStoreIamPolicy(buf, tc.ProjectID, location, datasetID, dicomStoreID, "roles/viewer", member); err != nil {
+			r.Errorf("setDICOMStoreIamPolicy got err: %v", err)
+		}
+		if got := buf.String(); !strings.Contains(got, member) {
+			r.Errorf("setDICOMStoreIamPolicy got %q; want to contain %q", got, member)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		studies, err := searchDICOMStudies(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID, nil)
+		if err != nil {
+			r.Errorf("searchDICOMStudies got err: %v", err)
+		}
+		if len(studies) != 0 {
+			r.Errorf("searchDICOMStudies on an empty store got %d studies, want 0", len(studies))
+		}
+	})
+
+	if err := setDICOMStoreBlobStorageSettings(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID, "GLACIER"); err == nil {
+		t.Errorf("setDICOMStoreBlobStorageSettings with an invalid storage class got nil err, want error")
+	}
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := setDICOMStoreBlobStorageSettings(buf, tc.ProjectID, location, datasetID, dicomStoreID, "NEARLINE"); err != nil {
+			r.Errorf("setDICOMStoreBlobStorageSettings got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "NEARLINE"; !strings.Contains(got, wantContain) {
+			r.Errorf("setDICOMStoreBlobStorageSettings got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	if err := configureDICOMStoreStreaming(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID, "bq://my-project.my_dataset"); err == nil {
+		t.Errorf("configureDICOMStoreStreaming without a table ID got nil err, want error")
+	}
+
+	if err := exportDICOMInstancesWithFilter(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID, "gs://my-bucket/", "gs://my-bucket/filter.txt", []string{"1.2.3"}); err == nil {
+		t.Errorf("exportDICOMInstancesWithFilter with two filter sources got nil err, want error")
+	}
+
+	// Run this once: a retry would store an instance that already exists.
+	buf.Reset()
+	files := []string{"testdata/dicom_00000001_000.dcm", "testdata/missing.dcm"}
+	if err := storeDICOMInstances(context.Background(), buf, tc.ProjectID, location, datasetID, dicomStoreID, files, 2); err == nil || !strings.Contains(err.Error(), "missing.dcm") {
+		t.Errorf("storeDICOMInstances with a missing file got err %v, want error about missing.dcm", err)
+	}
+	if got, wantContain := buf.String(), "Stored 1 of 2"; !strings.Contains(got, wantContain) {
+		t.Errorf("storeDICOMInstances got %q; want to contain %q", got, wantContain)
+	}
+
+	var studyUID string
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		studies, err := searchDICOMStudies(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID, nil)
+		if err != nil || len(studies) != 1 {
+			r.Errorf("searchDICOMStudies got %d studies, err %v; want 1 study", len(studies), err)
+			return
+		}
+		var study map[string]struct {
+			Value []string
+		}
+		if err := json.Unmarshal(studies[0], &study); err != nil || len(study["0020000D"].Value) == 0 {
+			r.Errorf("searchDICOMStudies got %s; want a StudyInstanceUID (%v)", studies[0], err)
+			return
+		}
+		studyUID = study["0020000D"].Value[0]
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		metadata, err := getDICOMStudyMetadata(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID, studyUID)
+		if err != nil {
+			r.Errorf("getDICOMStudyMetadata got err: %v", err)
+			return
+		}
+		if got := string(metadata); !strings.Contains(got, studyUID) {
+			r.Errorf("getDICOMStudyMetadata got %q; want to contain %q", got, studyUID)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := deleteDICOMInstance(buf, tc.ProjectID, location, datasetID, dicomStoreID, studyUID, "1.2.3", "4.5.6"); err != nil {
+			r.Errorf("deleteDICOMInstance for a missing instance got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "not found"; !strings.Contains(got, wantContain) {
+			r.Errorf("deleteDICOMInstance got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		series, err := searchDICOMSeries(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID, studyUID, nil)
+		if err != nil || len(series) != 1 {
+			r.Errorf("searchDICOMSeries got %d series, err %v; want 1 series", len(series), err)
+			return
+		}
+		var s map[string]struct {
+			Value []string
+		}
+		if err := json.Unmarshal(series[0], &s); err != nil || len(s["0020000E"].Value) == 0 {
+			r.Errorf("searchDICOMSeries got %s; want a SeriesInstanceUID (%v)", series[0], err)
+			return
+		}
+		if err := deleteDICOMSeries(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID, studyUID, s["0020000E"].Value[0]); err != nil {
+			r.Errorf("deleteDICOMSeries got err: %v", err)
+		}
+	})
+
+	if _, err := getDICOMStudyMetadata(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID, "1.2.3.4.5"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("getDICOMStudyMetadata for a missing study got err %v, want not found error", err)
+	}
+
+	deidentifiedStoreID := "my-dicom-store-deidentified"
+	if err := createDICOMStore(ioutil.Discard, tc.ProjectID, location, datasetID, deidentifiedStoreID); err != nil {
+		t.Errorf("createDICOMStore (deidentified) got err: %v", err)
+	}
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		destination := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", tc.ProjectID, location, datasetID, deidentifiedStoreID)
+		if err := deidentifyDICOMStore(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID, destination, "MINIMAL_KEEP_LIST_PROFILE"); err != nil {
+			r.Errorf("deidentifyDICOMStore got err: %v", err)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := deleteDICOMStore(ioutil.Discard, tc.ProjectID, location, datasetID, deidentifiedStoreID); err != nil {
+			r.Errorf("deleteDICOMStore (deidentified) got err: %v", err)
+		}
+	})
+
 	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
 		if err := deleteDICOMStore(ioutil.Discard, tc.ProjectID, location, datasetID, dicomStoreID); err != nil {
 			r.Errorf("deleteDICOMStore got err: %v", err)
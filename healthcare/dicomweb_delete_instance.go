@@ -0,0 +1,55 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_dicomweb_delete_instance]
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// deleteDICOMInstance deletes a single instance from a DICOM store. Deleting
+// an instance that doesn't exist is not an error.
+func deleteDICOMInstance(w io.Writer, projectID, location, datasetID, dicomStoreID, studyUID, seriesUID, instanceUID string) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	instancesService := healthcareService.Projects.Locations.Datasets.DicomStores.Studies.Series.Instances
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+	dicomWebPath := fmt.Sprintf("studies/%s/series/%s/instances/%s", studyUID, seriesUID, instanceUID)
+
+	if _, err := instancesService.Delete(parent, dicomWebPath).Do(); err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			fmt.Fprintf(w, "Instance %s not found; nothing to delete\n", dicomWebPath)
+			return nil
+		}
+		return fmt.Errorf("Delete: %v", err)
+	}
+
+	fmt.Fprintf(w, "Deleted instance %s\n", dicomWebPath)
+	return nil
+}
+
+// [END healthcare_dicomweb_delete_instance]
@@ -0,0 +1,82 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_dicomweb_delete_series]
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// deleteDICOMSeries deletes a series and all of its instances from a DICOM
+// store. Deleting a series that doesn't exist is not an error.
+func deleteDICOMSeries(w io.Writer, projectID, location, datasetID, dicomStoreID, studyUID, seriesUID string) error {
+	return deleteDICOMSeriesWithContext(context.Background(), w, projectID, location, datasetID, dicomStoreID, studyUID, seriesUID)
+}
+
+// deleteDICOMSeriesWithContext is like deleteDICOMSeries but uses ctx for its
+// API calls, including polling the operation.
+func deleteDICOMSeriesWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, dicomStoreID, studyUID, seriesUID string) error {
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	seriesService := healthcareService.Projects.Locations.Datasets.DicomStores.Studies.Series
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+	dicomWebPath := fmt.Sprintf("studies/%s/series/%s", studyUID, seriesUID)
+
+	lro, err := seriesService.Delete(parent, dicomWebPath).Context(ctx).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			fmt.Fprintf(w, "Series %s not found; nothing to delete\n", dicomWebPath)
+			return nil
+		}
+		return fmt.Errorf("Delete: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("delete: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("delete: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+	fmt.Fprintf(w, "Deleted series %s\n", dicomWebPath)
+	return nil
+}
+
+// [END healthcare_dicomweb_delete_series]
@@ -0,0 +1,64 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_dicomweb_retrieve_rendered]
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// retrieveRenderedDICOMInstance retrieves a DICOM instance rendered as a JPEG
+// image using WADO-RS and writes the image to w.
+func retrieveRenderedDICOMInstance(w io.Writer, projectID, location, datasetID, dicomStoreID, studyUID, seriesUID, instanceUID string) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	instancesService := healthcareService.Projects.Locations.Datasets.DicomStores.Studies.Series.Instances
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+	dicomWebPath := fmt.Sprintf("studies/%s/series/%s/instances/%s/rendered", studyUID, seriesUID, instanceUID)
+
+	call := instancesService.RetrieveRendered(parent, dicomWebPath)
+	call.Header().Set("Accept", "image/jpeg")
+	resp, err := call.Do()
+	if err != nil {
+		return fmt.Errorf("RetrieveRendered: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		respBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("could not read response: %v", err)
+		}
+		return fmt.Errorf("RetrieveRendered: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("io.Copy: %v", err)
+	}
+	return nil
+}
+
+// [END healthcare_dicomweb_retrieve_rendered]
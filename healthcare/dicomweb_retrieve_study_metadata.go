@@ -0,0 +1,68 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_dicomweb_retrieve_study_metadata]
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// getDICOMStudyMetadata retrieves the metadata of every instance in a study
+// using WADO-RS, without the bulk pixel data. It returns the DICOM JSON and
+// prints it to w.
+func getDICOMStudyMetadata(w io.Writer, projectID, location, datasetID, dicomStoreID, studyUID string) ([]byte, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	studiesService := healthcareService.Projects.Locations.Datasets.DicomStores.Studies
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+	dicomWebPath := fmt.Sprintf("studies/%s/metadata", studyUID)
+
+	call := studiesService.RetrieveMetadata(parent, dicomWebPath)
+	call.Header().Set("Accept", "application/dicom+json")
+	resp, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveMetadata: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %v", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("RetrieveMetadata: study %s not found in DICOM store %s", studyUID, dicomStoreID)
+	}
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("RetrieveMetadata: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+
+	fmt.Fprintf(w, "%s", respBytes)
+	return respBytes, nil
+}
+
+// [END healthcare_dicomweb_retrieve_study_metadata]
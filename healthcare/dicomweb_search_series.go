@@ -0,0 +1,78 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_dicomweb_search_series]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// searchDICOMSeries searches the series of a study in a DICOM store using
+// QIDO-RS. queryParams, such as {"Modality": "MR"}, narrow the search and may
+// be nil. Each element of the returned slice is the DICOM JSON of one series.
+func searchDICOMSeries(w io.Writer, projectID, location, datasetID, dicomStoreID, studyUID string, queryParams map[string]string) ([]json.RawMessage, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	studiesService := healthcareService.Projects.Locations.Datasets.DicomStores.Studies
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+	dicomWebPath := fmt.Sprintf("studies/%s/series", studyUID)
+
+	var opts []googleapi.CallOption
+	for k, v := range queryParams {
+		opts = append(opts, googleapi.QueryParameter(k, v))
+	}
+
+	resp, err := studiesService.SearchForSeries(parent, dicomWebPath).Do(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("SearchForSeries: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %v", err)
+	}
+
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("SearchForSeries: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+
+	// A 204 No Content response means there were no matches.
+	var series []json.RawMessage
+	if resp.StatusCode != http.StatusNoContent {
+		if err := json.Unmarshal(respBytes, &series); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %v", err)
+		}
+	}
+
+	fmt.Fprintf(w, "Found %d series in study %s\n", len(series), studyUID)
+	return series, nil
+}
+
+// [END healthcare_dicomweb_search_series]
@@ -0,0 +1,77 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_dicomweb_search_studies]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// searchDICOMStudies searches a DICOM store for studies using QIDO-RS.
+// queryParams, such as {"PatientName": "Smith"}, narrow the search and may be
+// nil. Each element of the returned slice is the DICOM JSON of one study.
+func searchDICOMStudies(w io.Writer, projectID, location, datasetID, dicomStoreID string, queryParams map[string]string) ([]json.RawMessage, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.DicomStores
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+
+	var opts []googleapi.CallOption
+	for k, v := range queryParams {
+		opts = append(opts, googleapi.QueryParameter(k, v))
+	}
+
+	resp, err := storesService.SearchForStudies(parent, "studies").Do(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("SearchForStudies: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %v", err)
+	}
+
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("SearchForStudies: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+
+	// A 204 No Content response means there were no matches.
+	var studies []json.RawMessage
+	if resp.StatusCode != http.StatusNoContent {
+		if err := json.Unmarshal(respBytes, &studies); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %v", err)
+		}
+	}
+
+	fmt.Fprintf(w, "Found %d studies\n", len(studies))
+	return studies, nil
+}
+
+// [END healthcare_dicomweb_search_studies]
@@ -0,0 +1,122 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_dicomweb_store_instances]
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// storeDICOMInstances uploads the DICOM files named by files to a DICOM store
+// using the DICOMweb STOW-RS service, uploading up to concurrency files at a
+// time. Errors for files that can't be uploaded are combined into the
+// returned error. If ctx is done first, the counts so far are still printed
+// to w.
+func storeDICOMInstances(ctx context.Context, w io.Writer, projectID, location, datasetID, dicomStoreID string, files []string, concurrency int) error {
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", concurrency)
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.DicomStores
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/dicomStores/%s", projectID, location, datasetID, dicomStoreID)
+
+	storeInstance := func(filename string) error {
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		call := storesService.StoreInstances(parent, "studies", f)
+		call.Header().Set("Content-Type", "application/dicom")
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("StoreInstances: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode > 299 {
+			respBytes, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return fmt.Errorf("could not read response: %v", err)
+			}
+			return fmt.Errorf("StoreInstances: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+		}
+		return nil
+	}
+
+	errs := make([]error, len(files))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := storeInstance(files[i]); err != nil {
+					errs[i] = fmt.Errorf("%s: %v", files[i], err)
+				}
+			}
+		}()
+	}
+
+	sent := 0
+send:
+	for i := range files {
+		select {
+		case indexes <- i:
+			sent++
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	var errMsgs []string
+	for _, err := range errs {
+		if err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+
+	stored := sent - len(errMsgs)
+	if err := ctx.Err(); err != nil {
+		fmt.Fprintf(w, "Stored %d of %d DICOM instances before stopping (failed: %d, not attempted: %d)\n", stored, len(files), len(errMsgs), len(files)-sent)
+		return err
+	}
+	fmt.Fprintf(w, "Stored %d of %d DICOM instances (failed: %d)\n", stored, len(files), len(errMsgs))
+	if len(errMsgs) > 0 {
+		return fmt.Errorf("failed to store %d instances: %s", len(errMsgs), strings.Join(errMsgs, "; "))
+	}
+	return nil
+}
+
+// [END healthcare_dicomweb_store_instances]
@@ -0,0 +1,87 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_export_fhir_resources_incremental]
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// exportFHIRResourcesIncremental exports the FHIR resources that changed after
+// since, an RFC3339 timestamp such as "2019-10-01T00:00:00Z", to GCS.
+// gcsURIPrefix has the form "gs://my-bucket/path/to/prefix/".
+func exportFHIRResourcesIncremental(w io.Writer, projectID, location, datasetID, fhirStoreID, gcsURIPrefix, since string) error {
+	return exportFHIRResourcesIncrementalWithContext(context.Background(), w, projectID, location, datasetID, fhirStoreID, gcsURIPrefix, since)
+}
+
+// exportFHIRResourcesIncrementalWithContext is like
+// exportFHIRResourcesIncremental but uses ctx for its API calls, including
+// polling the operation.
+func exportFHIRResourcesIncrementalWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, fhirStoreID, gcsURIPrefix, since string) error {
+	if _, err := time.Parse(time.RFC3339, since); err != nil {
+		return fmt.Errorf("invalid since timestamp %q: must be RFC3339: %v", since, err)
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.FhirStores
+
+	req := &healthcare.ExportResourcesRequest{
+		GcsDestination: &healthcare.GoogleCloudHealthcareV1beta1FhirRestGcsDestination{
+			UriPrefix: gcsURIPrefix,
+		},
+		Since: since,
+	}
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	lro, err := storesService.Export(name, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Export: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("export: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("export: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+	fmt.Fprintf(w, "Exported FHIR resources changed since %s to %s\n", since, gcsURIPrefix)
+	return nil
+}
+
+// [END healthcare_export_fhir_resources_incremental]
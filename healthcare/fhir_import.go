@@ -0,0 +1,102 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_import_fhir_resources]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// importFHIRResources imports FHIR resources from GCS into a FHIR store and
+// waits for the import to finish, reporting how many resources succeeded and
+// failed.
+//
+// contentURI has the form "gs://my-bucket/path/to/resources/*.ndjson". If
+// errorURIPrefix is not empty, details of each failed resource are written
+// under it, for example "gs://my-bucket/import-errors/".
+func importFHIRResources(w io.Writer, projectID, location, datasetID, fhirStoreID, contentURI, errorURIPrefix string) error {
+	return importFHIRResourcesWithContext(context.Background(), w, projectID, location, datasetID, fhirStoreID, contentURI, errorURIPrefix)
+}
+
+// importFHIRResourcesWithContext is like importFHIRResources but uses ctx for
+// its API calls, including polling the operation.
+func importFHIRResourcesWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, fhirStoreID, contentURI, errorURIPrefix string) error {
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.FhirStores
+
+	req := &healthcare.ImportResourcesRequest{
+		ContentStructure: "RESOURCE",
+		GcsSource: &healthcare.GoogleCloudHealthcareV1beta1FhirRestGcsSource{
+			Uri: contentURI,
+		},
+	}
+	if errorURIPrefix != "" {
+		req.GcsErrorDestination = &healthcare.GoogleCloudHealthcareV1beta1FhirRestGcsErrorDestination{
+			UriPrefix: errorURIPrefix,
+		}
+	}
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	lro, err := storesService.Import(name, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Import: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("import: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("import: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+	var metadata healthcare.OperationMetadata
+	if err := json.Unmarshal(op.Metadata, &metadata); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+	if metadata.Counter == nil {
+		metadata.Counter = &healthcare.ProgressCounter{}
+	}
+	fmt.Fprintf(w, "Imported FHIR resources from %s (succeeded: %d, failed: %d)\n", contentURI, metadata.Counter.Success, metadata.Counter.Failure)
+	if metadata.Counter.Failure > 0 && errorURIPrefix != "" {
+		fmt.Fprintf(w, "Details of failed resources were written to %s\n", errorURIPrefix)
+	}
+	return nil
+}
+
+// [END healthcare_import_fhir_resources]
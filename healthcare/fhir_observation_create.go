@@ -0,0 +1,101 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_create_observation]
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// fhirIDRE matches a FHIR resource ID.
+var fhirIDRE = regexp.MustCompile(`^[A-Za-z0-9\-.]{1,64}$`)
+
+// createObservation creates an Observation for the Patient with ID patientID
+// recording a single measurement, such as a heart rate with LOINC code
+// "8867-4", value 72, and unit "/min".
+func createObservation(w io.Writer, projectID, location, datasetID, fhirStoreID, patientID, code string, valueQuantity float64, unit string) error {
+	if !fhirIDRE.MatchString(patientID) {
+		return fmt.Errorf("invalid patient ID %q: want the ID of a Patient resource, not a reference such as Patient/123", patientID)
+	}
+
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	fhirService := healthcareService.Projects.Locations.Datasets.FhirStores.Fhir
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	observation := map[string]interface{}{
+		"resourceType": "Observation",
+		"status":       "final",
+		"subject": map[string]string{
+			"reference": "Patient/" + patientID,
+		},
+		"code": map[string]interface{}{
+			"coding": []map[string]string{
+				{"system": "http://loinc.org", "code": code},
+			},
+		},
+		"valueQuantity": map[string]interface{}{
+			"value":  valueQuantity,
+			"unit":   unit,
+			"system": "http://unitsofmeasure.org",
+			"code":   unit,
+		},
+	}
+	body, err := json.Marshal(observation)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %v", err)
+	}
+
+	call := fhirService.Create(parent, "Observation", bytes.NewReader(body))
+	call.Header().Set("Content-Type", "application/fhir+json;charset=utf-8")
+	resp, err := call.Do()
+	if err != nil {
+		return fmt.Errorf("Create: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response: %v", err)
+	}
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("Create: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(respBytes, &created); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+	fmt.Fprintf(w, "Created Observation %s for Patient/%s\n", created.ID, patientID)
+	return nil
+}
+
+// [END healthcare_create_observation]
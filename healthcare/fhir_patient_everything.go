@@ -0,0 +1,108 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_get_patient_everything]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// getPatientEverything gets every resource in a patient's compartment using
+// the Patient $everything operation. It follows the "next" links of the
+// response to collect every page and returns a single Bundle containing all
+// of the entries.
+func getPatientEverything(w io.Writer, projectID, location, datasetID, fhirStoreID, patientID string) ([]byte, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	fhirService := healthcareService.Projects.Locations.Datasets.FhirStores.Fhir
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s/fhir/Patient/%s", projectID, location, datasetID, fhirStoreID, patientID)
+
+	var entries []json.RawMessage
+	pageToken := ""
+	for {
+		call := fhirService.PatientEverything(name)
+		if pageToken != "" {
+			call.PageToken(pageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("PatientEverything: %v", err)
+		}
+
+		respBytes, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not read response: %v", err)
+		}
+		if resp.StatusCode > 299 {
+			return nil, fmt.Errorf("PatientEverything: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+		}
+
+		var page struct {
+			Link []struct {
+				Relation string `json:"relation"`
+				URL      string `json:"url"`
+			} `json:"link"`
+			Entry []json.RawMessage `json:"entry"`
+		}
+		if err := json.Unmarshal(respBytes, &page); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %v", err)
+		}
+		entries = append(entries, page.Entry...)
+
+		pageToken = ""
+		for _, link := range page.Link {
+			if link.Relation != "next" {
+				continue
+			}
+			next, err := url.Parse(link.URL)
+			if err != nil {
+				return nil, fmt.Errorf("url.Parse: %v", err)
+			}
+			pageToken = next.Query().Get("_page_token")
+		}
+		if pageToken == "" {
+			break
+		}
+	}
+
+	bundle, err := json.Marshal(map[string]interface{}{
+		"resourceType": "Bundle",
+		"type":         "searchset",
+		"total":        len(entries),
+		"entry":        entries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %v", err)
+	}
+
+	fmt.Fprintf(w, "Got %d entries for patient %s\n", len(entries), patientID)
+	return bundle, nil
+}
+
+// [END healthcare_get_patient_everything]
@@ -0,0 +1,86 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_conditional_delete_resource]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// conditionalDeleteFHIRResources deletes all resources of type resourceType
+// matching searchParams, such as {"identifier": "urn:mrn|12345"}. At least
+// one search parameter is required so that a mistake can't delete every
+// resource of the type.
+func conditionalDeleteFHIRResources(w io.Writer, projectID, location, datasetID, fhirStoreID, resourceType string, searchParams map[string]string) error {
+	if len(searchParams) == 0 {
+		return fmt.Errorf("at least one search parameter is required to delete %s resources", resourceType)
+	}
+
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	fhirService := healthcareService.Projects.Locations.Datasets.FhirStores.Fhir
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	var opts []googleapi.CallOption
+	for k, v := range searchParams {
+		opts = append(opts, googleapi.QueryParameter(k, v))
+	}
+
+	// The API doesn't report how many resources a conditional delete removes,
+	// so count the matches first. Resources that change between the two calls
+	// can make the count differ from the number actually deleted.
+	countOpts := append([]googleapi.CallOption{googleapi.QueryParameter("_summary", "count")}, opts...)
+	resp, err := fhirService.Search(parent, &healthcare.SearchResourcesRequest{ResourceType: resourceType}).Do(countOpts...)
+	if err != nil {
+		return fmt.Errorf("Search: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response: %v", err)
+	}
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("Search: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+	var bundle struct {
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(respBytes, &bundle); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	if _, err := fhirService.ConditionalDelete(parent, resourceType).Do(opts...); err != nil {
+		return fmt.Errorf("ConditionalDelete: %v", err)
+	}
+
+	fmt.Fprintf(w, "Deleted %s resources matching %v (%d matched before the delete)\n", resourceType, searchParams, bundle.Total)
+	return nil
+}
+
+// [END healthcare_conditional_delete_resource]
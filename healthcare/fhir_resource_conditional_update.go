@@ -0,0 +1,73 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_conditional_update_resource]
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// conditionalUpdateFHIRResource updates the resource of type resourceType
+// matching searchParams, such as {"identifier": "urn:mrn|12345"}, with body.
+// If no resource matches, a new resource is created from body.
+func conditionalUpdateFHIRResource(w io.Writer, projectID, location, datasetID, fhirStoreID, resourceType string, searchParams map[string]string, body []byte) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	fhirService := healthcareService.Projects.Locations.Datasets.FhirStores.Fhir
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	call := fhirService.ConditionalUpdate(parent, resourceType, bytes.NewReader(body))
+	call.Header().Set("Content-Type", "application/fhir+json;charset=utf-8")
+
+	var opts []googleapi.CallOption
+	for k, v := range searchParams {
+		opts = append(opts, googleapi.QueryParameter(k, v))
+	}
+	resp, err := call.Do(opts...)
+	if err != nil {
+		return fmt.Errorf("ConditionalUpdate: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response: %v", err)
+	}
+
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("ConditionalUpdate: more than one %s matches %v; narrow the search parameters: %s", resourceType, searchParams, respBytes)
+	}
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("ConditionalUpdate: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+	fmt.Fprintf(w, "%s", respBytes)
+	return nil
+}
+
+// [END healthcare_conditional_update_resource]
@@ -0,0 +1,88 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_get_resource_by_identifier]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"google.golang.org/api/googleapi"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// getFHIRResourceByIdentifier returns the single resource of type
+// resourceType whose identifier has the given system and value, such as an
+// MRN. It returns an error if no resource or more than one resource matches.
+func getFHIRResourceByIdentifier(w io.Writer, projectID, location, datasetID, fhirStoreID, resourceType, system, value string) ([]byte, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	fhirService := healthcareService.Projects.Locations.Datasets.FhirStores.Fhir
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	identifier := system + "|" + value
+	// Two results are enough to tell an exact match from an ambiguous one.
+	resp, err := fhirService.Search(parent, &healthcare.SearchResourcesRequest{ResourceType: resourceType}).Do(
+		googleapi.QueryParameter("identifier", identifier),
+		googleapi.QueryParameter("_count", "2"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Search: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %v", err)
+	}
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("Search: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+
+	var bundle struct {
+		Total int `json:"total"`
+		Entry []struct {
+			Resource json.RawMessage `json:"resource"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal(respBytes, &bundle); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	matches := bundle.Total
+	if matches < len(bundle.Entry) {
+		matches = len(bundle.Entry)
+	}
+	switch {
+	case matches == 0:
+		return nil, fmt.Errorf("no %s resource with identifier %q", resourceType, identifier)
+	case matches > 1:
+		return nil, fmt.Errorf("%d %s resources have identifier %q, want exactly one", matches, resourceType, identifier)
+	}
+
+	fmt.Fprintf(w, "%s", bundle.Entry[0].Resource)
+	return bundle.Entry[0].Resource, nil
+}
+
+// [END healthcare_get_resource_by_identifier]
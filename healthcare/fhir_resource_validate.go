@@ -0,0 +1,88 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_validate_resource]
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// validateFHIRResource validates body against the profile of resourceType
+// using the FHIR $validate operation, without storing it. It reports whether
+// the resource is valid and prints the issues found to w.
+func validateFHIRResource(w io.Writer, projectID, location, datasetID, fhirStoreID, resourceType string, body []byte) (bool, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return false, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	fhirService := healthcareService.Projects.Locations.Datasets.FhirStores.Fhir
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	call := fhirService.ResourceValidate(parent, resourceType, bytes.NewReader(body))
+	call.Header().Set("Content-Type", "application/fhir+json;charset=utf-8")
+	resp, err := call.Do()
+	if err != nil {
+		return false, fmt.Errorf("ResourceValidate: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("could not read response: %v", err)
+	}
+
+	// An invalid resource is reported as a 400 response with an
+	// OperationOutcome describing the problems.
+	if resp.StatusCode > 299 && resp.StatusCode != http.StatusBadRequest {
+		return false, fmt.Errorf("ResourceValidate: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+
+	var outcome struct {
+		ResourceType string `json:"resourceType"`
+		Issue        []struct {
+			Severity    string `json:"severity"`
+			Code        string `json:"code"`
+			Diagnostics string `json:"diagnostics"`
+		} `json:"issue"`
+	}
+	if err := json.Unmarshal(respBytes, &outcome); err != nil || outcome.ResourceType != "OperationOutcome" {
+		return false, fmt.Errorf("ResourceValidate: status %d %s: unexpected response: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+
+	valid := resp.StatusCode == http.StatusOK
+	if valid {
+		fmt.Fprintf(w, "%s is valid\n", resourceType)
+	} else {
+		fmt.Fprintf(w, "%s is invalid:\n", resourceType)
+	}
+	for _, issue := range outcome.Issue {
+		fmt.Fprintf(w, "%s (%s): %s\n", issue.Severity, issue.Code, issue.Diagnostics)
+	}
+	return valid, nil
+}
+
+// [END healthcare_validate_resource]
@@ -0,0 +1,179 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_seed_fhir_resources]
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// seedBundleThreshold is the number of resources above which
+// seedFHIRResources sends a single batch bundle instead of one request per
+// resource.
+const seedBundleThreshold = 10
+
+// seedFHIRResources creates each of resources, which are FHIR resources in
+// JSON format, in a FHIR store. It returns the ID assigned to each resource,
+// in the same order. If some resources can't be created, their IDs are empty
+// and the returned error describes each failure.
+func seedFHIRResources(w io.Writer, projectID, location, datasetID, fhirStoreID string, resources [][]byte) ([]string, error) {
+	resourceTypes := make([]string, len(resources))
+	for i, r := range resources {
+		var resource struct {
+			ResourceType string `json:"resourceType"`
+		}
+		if err := json.Unmarshal(r, &resource); err != nil || resource.ResourceType == "" {
+			return nil, fmt.Errorf("resource %d: not a FHIR resource with a resourceType", i)
+		}
+		resourceTypes[i] = resource.ResourceType
+	}
+
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	fhirService := healthcareService.Projects.Locations.Datasets.FhirStores.Fhir
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	ids := make([]string, len(resources))
+	var errs []string
+
+	if len(resources) <= seedBundleThreshold {
+		for i, r := range resources {
+			id, err := createSeedResource(fhirService, parent, resourceTypes[i], r)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("resource %d: %v", i, err))
+				continue
+			}
+			ids[i] = id
+		}
+	} else {
+		type bundleEntry struct {
+			Resource json.RawMessage `json:"resource"`
+			Request  struct {
+				Method string `json:"method"`
+				URL    string `json:"url"`
+			} `json:"request"`
+		}
+		bundle := struct {
+			ResourceType string        `json:"resourceType"`
+			Type         string        `json:"type"`
+			Entry        []bundleEntry `json:"entry"`
+		}{ResourceType: "Bundle", Type: "batch"}
+		for i, r := range resources {
+			e := bundleEntry{Resource: r}
+			e.Request.Method = "POST"
+			e.Request.URL = resourceTypes[i]
+			bundle.Entry = append(bundle.Entry, e)
+		}
+		bundleBytes, err := json.Marshal(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("json.Marshal: %v", err)
+		}
+
+		call := fhirService.ExecuteBundle(parent, bytes.NewReader(bundleBytes))
+		call.Header().Set("Content-Type", "application/fhir+json;charset=utf-8")
+		resp, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("ExecuteBundle: %v", err)
+		}
+		defer resp.Body.Close()
+
+		respBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("could not read response: %v", err)
+		}
+		if resp.StatusCode > 299 {
+			return nil, fmt.Errorf("ExecuteBundle: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+		}
+
+		// Entries in a batch response are in the same order as the request.
+		var respBundle struct {
+			Entry []struct {
+				Response struct {
+					Status   string          `json:"status"`
+					Location string          `json:"location"`
+					Outcome  json.RawMessage `json:"outcome"`
+				} `json:"response"`
+			} `json:"entry"`
+		}
+		if err := json.Unmarshal(respBytes, &respBundle); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %v", err)
+		}
+		if len(respBundle.Entry) != len(resources) {
+			return nil, fmt.Errorf("ExecuteBundle: got %d response entries, want %d", len(respBundle.Entry), len(resources))
+		}
+		for i, e := range respBundle.Entry {
+			if !strings.HasPrefix(e.Response.Status, "2") {
+				errs = append(errs, fmt.Sprintf("resource %d: status %s: %s", i, e.Response.Status, e.Response.Outcome))
+				continue
+			}
+			// The location has the form "TYPE/ID/_history/VERSION".
+			parts := strings.Split(e.Response.Location, "/")
+			if len(parts) < 2 {
+				errs = append(errs, fmt.Sprintf("resource %d: unexpected location %q", i, e.Response.Location))
+				continue
+			}
+			ids[i] = parts[1]
+		}
+	}
+
+	fmt.Fprintf(w, "Seeded %d of %d FHIR resources\n", len(resources)-len(errs), len(resources))
+	if len(errs) > 0 {
+		return ids, fmt.Errorf("could not create %d resource(s): %s", len(errs), strings.Join(errs, "; "))
+	}
+	return ids, nil
+}
+
+// createSeedResource creates a single resource and returns its assigned ID.
+func createSeedResource(fhirService *healthcare.ProjectsLocationsDatasetsFhirStoresFhirService, parent, resourceType string, body []byte) (string, error) {
+	call := fhirService.Create(parent, resourceType, bytes.NewReader(body))
+	call.Header().Set("Content-Type", "application/fhir+json;charset=utf-8")
+	resp, err := call.Do()
+	if err != nil {
+		return "", fmt.Errorf("Create: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not read response: %v", err)
+	}
+	if resp.StatusCode > 299 {
+		return "", fmt.Errorf("Create: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(respBytes, &created); err != nil {
+		return "", fmt.Errorf("json.Unmarshal: %v", err)
+	}
+	return created.ID, nil
+}
+
+// [END healthcare_seed_fhir_resources]
@@ -0,0 +1,159 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_copy_fhir_store]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"cloud.google.com/go/storage"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/iterator"
+)
+
+// copyFHIRStore copies every resource in a FHIR store into another, existing
+// FHIR store, which may be in a different dataset, location, or project.
+//
+// The API has no call that copies resources between stores, so the resources
+// are exported to a temporary prefix in the GCS bucket tempBucket, imported
+// from there, and then the temporary objects are deleted. That is why the
+// caller must name a bucket that both stores' service agents can access. An
+// error from deleting the temporary objects is only returned if the copy
+// itself succeeded.
+func copyFHIRStore(w io.Writer, srcProjectID, srcLocation, srcDatasetID, srcStoreID, dstProjectID, dstLocation, dstDatasetID, dstStoreID, tempBucket string) error {
+	return copyFHIRStoreWithContext(context.Background(), w, srcProjectID, srcLocation, srcDatasetID, srcStoreID, dstProjectID, dstLocation, dstDatasetID, dstStoreID, tempBucket)
+}
+
+// copyFHIRStoreWithContext is like copyFHIRStore but uses ctx for its API
+// calls, including polling the operations.
+func copyFHIRStoreWithContext(ctx context.Context, w io.Writer, srcProjectID, srcLocation, srcDatasetID, srcStoreID, dstProjectID, dstLocation, dstDatasetID, dstStoreID, tempBucket string) (err error) {
+	srcName := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", srcProjectID, srcLocation, srcDatasetID, srcStoreID)
+	dstName := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", dstProjectID, dstLocation, dstDatasetID, dstStoreID)
+	if srcName == dstName {
+		return fmt.Errorf("source and destination are the same FHIR store %q", srcName)
+	}
+	if tempBucket == "" {
+		return fmt.Errorf("a GCS bucket for temporary files is required")
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storageClient, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %v", err)
+	}
+	defer storageClient.Close()
+
+	storesService := healthcareService.Projects.Locations.Datasets.FhirStores
+
+	// wait polls an operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes, and returns its success
+	// and failure counters.
+	wait := func(verb string, lro *healthcare.Operation) (success, failure int64, err error) {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+		defer cancel()
+		op := lro
+		for delay := time.Second; !op.Done; {
+			select {
+			case <-ctx.Done():
+				return 0, 0, fmt.Errorf("%s: operation %q did not finish: %v", verb, lro.Name, ctx.Err())
+			case <-time.After(delay):
+			}
+			if delay *= 2; delay > 30*time.Second {
+				delay = 30 * time.Second
+			}
+			if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+				return 0, 0, fmt.Errorf("Operations.Get: %v", err)
+			}
+		}
+		if op.Error != nil {
+			return 0, 0, fmt.Errorf("%s: operation %q failed: %s", verb, op.Name, op.Error.Message)
+		}
+		var metadata healthcare.OperationMetadata
+		if err := json.Unmarshal(op.Metadata, &metadata); err != nil {
+			return 0, 0, fmt.Errorf("json.Unmarshal: %v", err)
+		}
+		if metadata.Counter == nil {
+			return 0, 0, nil
+		}
+		return metadata.Counter.Success, metadata.Counter.Failure, nil
+	}
+
+	tempPrefix := fmt.Sprintf("copy-fhir-store-%d", time.Now().UnixNano())
+	tempURI := fmt.Sprintf("gs://%s/%s", tempBucket, tempPrefix)
+
+	// Delete the exported files whether or not the copy succeeds, without
+	// replacing an error from the copy.
+	defer func() {
+		bucket := storageClient.Bucket(tempBucket)
+		it := bucket.Objects(ctx, &storage.Query{Prefix: tempPrefix + "/"})
+		for {
+			attrs, iterErr := it.Next()
+			if iterErr == iterator.Done {
+				break
+			}
+			if iterErr != nil {
+				if err == nil {
+					err = fmt.Errorf("could not list temporary objects under %s: %v", tempURI, iterErr)
+				}
+				return
+			}
+			if delErr := bucket.Object(attrs.Name).Delete(ctx); delErr != nil && err == nil {
+				err = fmt.Errorf("could not delete temporary object %q: %v", attrs.Name, delErr)
+			}
+		}
+	}()
+
+	exportReq := &healthcare.ExportResourcesRequest{
+		GcsDestination: &healthcare.GoogleCloudHealthcareV1beta1FhirRestGcsDestination{
+			UriPrefix: tempURI,
+		},
+	}
+	lro, err := storesService.Export(srcName, exportReq).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Export: %v", err)
+	}
+	exported, _, err := wait("export", lro)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Exported %d resources from %s to %s\n", exported, srcName, tempURI)
+
+	importReq := &healthcare.ImportResourcesRequest{
+		ContentStructure: "RESOURCE",
+		GcsSource: &healthcare.GoogleCloudHealthcareV1beta1FhirRestGcsSource{
+			Uri: tempURI + "/*",
+		},
+	}
+	lro, err = storesService.Import(dstName, importReq).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Import: %v", err)
+	}
+	imported, failed, err := wait("import", lro)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Imported resources into %s (succeeded: %d, failed: %d)\n", dstName, imported, failed)
+	return nil
+}
+
+// [END healthcare_copy_fhir_store]
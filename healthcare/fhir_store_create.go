@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // createFHIRStore creates an FHIR store.
 func createFHIRStore(w io.Writer, projectID, location, datasetID, fhirStoreID string) error {
-	ctx := context.Background()
+	return createFHIRStoreWithContext(context.Background(), w, projectID, location, datasetID, fhirStoreID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// createFHIRStoreWithContext is like createFHIRStore but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func createFHIRStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, fhirStoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -37,7 +42,7 @@ func createFHIRStore(w io.Writer, projectID, location, datasetID, fhirStoreID st
 	store := &healthcare.FhirStore{}
 	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
 
-	resp, err := storesService.Create(parent, store).FhirStoreId(fhirStoreID).Do()
+	resp, err := storesService.Create(parent, store).FhirStoreId(fhirStoreID).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("Create: %v", err)
 	}
@@ -0,0 +1,72 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_create_fhir_store_search_handling]
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// createFHIRStoreWithSearchHandling creates a FHIR store that opts into
+// strict search handling, disables resource versioning, or both.
+//
+// With strictSearch, searches that use unknown or unsupported parameters fail
+// instead of ignoring them. With disableVersioning, only the current version
+// of each resource is kept, which saves storage but means _history requests
+// and rollbacks aren't possible.
+func createFHIRStoreWithSearchHandling(w io.Writer, projectID, location, datasetID, fhirStoreID string, strictSearch, disableVersioning bool) error {
+	return createFHIRStoreWithSearchHandlingWithContext(context.Background(), w, projectID, location, datasetID, fhirStoreID, strictSearch, disableVersioning)
+}
+
+// createFHIRStoreWithSearchHandlingWithContext is like createFHIRStoreWithSearchHandling but uses ctx for its API calls.
+func createFHIRStoreWithSearchHandlingWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, fhirStoreID string, strictSearch, disableVersioning bool) error {
+	if !strictSearch && !disableVersioning {
+		return errors.New("neither strict search nor disabled versioning requested; use createFHIRStore for a default store")
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.FhirStores
+
+	store := &healthcare.FhirStore{
+		DefaultSearchHandlingStrict: strictSearch,
+		DisableResourceVersioning:   disableVersioning,
+	}
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
+
+	if disableVersioning {
+		fmt.Fprintln(w, "Warning: resource versioning is disabled; _history and rollback won't be available for this store")
+	}
+
+	resp, err := storesService.Create(parent, store).FhirStoreId(fhirStoreID).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Create: %v", err)
+	}
+
+	fmt.Fprintf(w, "Created FHIR store: %q\n", resp.Name)
+	fmt.Fprintf(w, "Strict search handling: %t\n", resp.DefaultSearchHandlingStrict)
+	fmt.Fprintf(w, "Resource versioning disabled: %t\n", resp.DisableResourceVersioning)
+	return nil
+}
+
+// [END healthcare_create_fhir_store_search_handling]
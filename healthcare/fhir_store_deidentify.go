@@ -0,0 +1,96 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_deidentify_fhir_store]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// deidentifyFHIRStore de-identifies the resources in a FHIR store and writes
+// them to the existing FHIR store destinationStoreName, which has the form
+// "projects/*/locations/*/datasets/*/fhirStores/*".
+//
+// fhirConfig controls which fields are transformed. If it is nil, every field
+// is de-identified using the default transformations.
+func deidentifyFHIRStore(w io.Writer, projectID, location, datasetID, sourceStoreID, destinationStoreName string, fhirConfig *healthcare.FhirConfig) error {
+	return deidentifyFHIRStoreWithContext(context.Background(), w, projectID, location, datasetID, sourceStoreID, destinationStoreName, fhirConfig)
+}
+
+// deidentifyFHIRStoreWithContext is like deidentifyFHIRStore but uses ctx for
+// its API calls, including polling the operation.
+func deidentifyFHIRStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, sourceStoreID, destinationStoreName string, fhirConfig *healthcare.FhirConfig) error {
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.FhirStores
+
+	if fhirConfig == nil {
+		fhirConfig = &healthcare.FhirConfig{}
+	}
+	req := &healthcare.DeidentifyFhirStoreRequest{
+		DestinationStore: destinationStoreName,
+		Config: &healthcare.DeidentifyConfig{
+			Fhir: fhirConfig,
+		},
+	}
+
+	sourceName := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, sourceStoreID)
+	lro, err := storesService.Deidentify(sourceName, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Deidentify: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("deidentify: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("deidentify: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+	var metadata healthcare.OperationMetadata
+	if err := json.Unmarshal(op.Metadata, &metadata); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+	if metadata.Counter == nil {
+		metadata.Counter = &healthcare.ProgressCounter{}
+	}
+	fmt.Fprintf(w, "De-identified FHIR store %s into %s (succeeded: %d, failed: %d)\n", sourceName, destinationStoreName, metadata.Counter.Success, metadata.Counter.Failure)
+	return nil
+}
+
+// [END healthcare_deidentify_fhir_store]
@@ -21,13 +21,19 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // deleteFHIRStore deletes an FHIR store.
 func deleteFHIRStore(w io.Writer, projectID, location, datasetID, fhirStoreID string) error {
-	ctx := context.Background()
+	return deleteFHIRStoreWithContext(context.Background(), w, projectID, location, datasetID, fhirStoreID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// deleteFHIRStoreWithContext is like deleteFHIRStore but uses ctx for its API
+// calls, so the caller can bound how long they take or cancel them. opts
+// configure the service, for example its credentials or endpoint.
+func deleteFHIRStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, fhirStoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -36,7 +42,7 @@ func deleteFHIRStore(w io.Writer, projectID, location, datasetID, fhirStoreID st
 
 	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
 
-	if _, err := storesService.Delete(name).Do(); err != nil {
+	if _, err := storesService.Delete(name).Context(ctx).Do(); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
 
@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // getFHIRStore gets an FHIR store.
 func getFHIRStore(w io.Writer, projectID, location, datasetID, fhirStoreID string) error {
-	ctx := context.Background()
+	return getFHIRStoreWithContext(context.Background(), w, projectID, location, datasetID, fhirStoreID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// getFHIRStoreWithContext is like getFHIRStore but uses ctx for its API calls.
+// opts configure the service, for example its credentials or endpoint.
+func getFHIRStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, fhirStoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -36,7 +41,7 @@ func getFHIRStore(w io.Writer, projectID, location, datasetID, fhirStoreID strin
 
 	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
 
-	store, err := storesService.Get(name).Do()
+	store, err := storesService.Get(name).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("Get: %v", err)
 	}
@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // listFHIRStores prints a list of FHIR stores to w.
 func listFHIRStores(w io.Writer, projectID, location, datasetID string) error {
-	ctx := context.Background()
+	return listFHIRStoresWithContext(context.Background(), w, projectID, location, datasetID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// listFHIRStoresWithContext is like listFHIRStores but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func listFHIRStoresWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -36,7 +41,7 @@ func listFHIRStores(w io.Writer, projectID, location, datasetID string) error {
 
 	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
 
-	resp, err := storesService.List(parent).Do()
+	resp, err := storesService.List(parent).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("List: %v", err)
 	}
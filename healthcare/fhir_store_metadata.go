@@ -0,0 +1,68 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_get_fhir_store_metadata]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// getFHIRStoreMetadata gets the CapabilityStatement of a FHIR store, which
+// describes the resource types and search parameters it supports.
+func getFHIRStoreMetadata(w io.Writer, projectID, location, datasetID, fhirStoreID string) ([]byte, error) {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	fhirService := healthcareService.Projects.Locations.Datasets.FhirStores.Fhir
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	resp, err := fhirService.Capabilities(name).Do()
+	if err != nil {
+		return nil, fmt.Errorf("Capabilities: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %v", err)
+	}
+
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("Capabilities: status %d %s: %s", resp.StatusCode, resp.Status, respBytes)
+	}
+
+	var capabilities struct {
+		FHIRVersion string `json:"fhirVersion"`
+	}
+	if err := json.Unmarshal(respBytes, &capabilities); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+	fmt.Fprintf(w, "FHIR version: %s\n", capabilities.FHIRVersion)
+
+	return respBytes, nil
+}
+
+// [END healthcare_get_fhir_store_metadata]
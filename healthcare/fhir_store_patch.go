@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // patchFHIRStore updates (patches) a FHIR store by updating its Pub/sub topic name.
 func patchFHIRStore(w io.Writer, projectID, location, datasetID, fhirStoreID, topicName string) error {
-	ctx := context.Background()
+	return patchFHIRStoreWithContext(context.Background(), w, projectID, location, datasetID, fhirStoreID, topicName)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// patchFHIRStoreWithContext is like patchFHIRStore but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func patchFHIRStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, fhirStoreID, topicName string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -40,7 +45,7 @@ func patchFHIRStore(w io.Writer, projectID, location, datasetID, fhirStoreID, to
 		NotificationConfig: &healthcare.NotificationConfig{
 			PubsubTopic: topicName, // format is "projects/*/locations/*/topics/*"
 		},
-	}).UpdateMask("notificationConfig").Do(); err != nil {
+	}).UpdateMask("notificationConfig").Context(ctx).Do(); err != nil {
 		return fmt.Errorf("Patch: %v", err)
 	}
 
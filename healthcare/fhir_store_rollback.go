@@ -0,0 +1,97 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_rollback_fhir_store]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// rollbackFHIRStore reverts every resource in a FHIR store to its state at
+// rollbackTime, for example to undo a bad import. The API writes a record
+// of each resource it rolled back to resultGCSBucket, which has the form
+// "gs://bucket".
+func rollbackFHIRStore(w io.Writer, projectID, location, datasetID, fhirStoreID, resultGCSBucket string, rollbackTime time.Time) error {
+	return rollbackFHIRStoreWithContext(context.Background(), w, projectID, location, datasetID, fhirStoreID, resultGCSBucket, rollbackTime)
+}
+
+// rollbackFHIRStoreWithContext is like rollbackFHIRStore but uses ctx for its
+// API calls, including polling the operation.
+func rollbackFHIRStoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, fhirStoreID, resultGCSBucket string, rollbackTime time.Time) error {
+	if !rollbackTime.Before(time.Now()) {
+		return fmt.Errorf("rollback time %v must be in the past", rollbackTime)
+	}
+	if !strings.HasPrefix(resultGCSBucket, "gs://") {
+		return fmt.Errorf("invalid result GCS bucket %q: must start with gs://", resultGCSBucket)
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.FhirStores
+
+	req := &healthcare.RollbackFhirResourcesRequest{
+		RollbackTime:    rollbackTime.UTC().Format(time.RFC3339Nano),
+		ResultGcsBucket: resultGCSBucket,
+	}
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	lro, err := storesService.Rollback(name, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Rollback: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("rollback: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("rollback: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+	var metadata healthcare.OperationMetadata
+	if err := json.Unmarshal(op.Metadata, &metadata); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+	if metadata.Counter == nil {
+		metadata.Counter = &healthcare.ProgressCounter{}
+	}
+	fmt.Fprintf(w, "Rolled back FHIR store %s to %v (succeeded: %d, failed: %d)\n", name, rollbackTime, metadata.Counter.Success, metadata.Counter.Failure)
+	return nil
+}
+
+// [END healthcare_rollback_fhir_store]
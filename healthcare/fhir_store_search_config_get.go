@@ -0,0 +1,56 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_get_fhir_store_search_config]
+import (
+	"context"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// getFHIRStoreSearchConfig prints the search parameters enabled on a FHIR
+// store in addition to the ones defined by the FHIR specification.
+func getFHIRStoreSearchConfig(w io.Writer, projectID, location, datasetID, fhirStoreID string) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.FhirStores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	store, err := storesService.Get(name).Do()
+	if err != nil {
+		return fmt.Errorf("Get: %v", err)
+	}
+
+	if store.SearchConfig == nil || len(store.SearchConfig.SearchParameters) == 0 {
+		fmt.Fprintf(w, "FHIR store %q has no custom search parameters\n", store.Name)
+		return nil
+	}
+	fmt.Fprintf(w, "FHIR store %q search parameters:\n", store.Name)
+	for _, p := range store.SearchConfig.SearchParameters {
+		fmt.Fprintf(w, "  %s (%s)\n", p.CanonicalUrl, p.Parameter)
+	}
+	return nil
+}
+
+// [END healthcare_get_fhir_store_search_config]
@@ -0,0 +1,82 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_patch_fhir_store_search_config]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// searchParameterNameRE matches the resource name of a SearchParameter
+// resource stored in a FHIR store.
+var searchParameterNameRE = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/datasets/[^/]+/fhirStores/[^/]+/fhir/SearchParameter/[^/]+(/_history/[^/]+)?$`)
+
+// patchFHIRStoreSearchConfig updates (patches) a FHIR store's search config
+// to enable the given search parameters, such as custom parameters that
+// search on extensions. Each parameter must reference a SearchParameter
+// resource that has already been created in the store.
+func patchFHIRStoreSearchConfig(w io.Writer, projectID, location, datasetID, fhirStoreID string, params []*healthcare.SearchParameter) error {
+	if len(params) == 0 {
+		return fmt.Errorf("no search parameters given")
+	}
+	for _, p := range params {
+		if p == nil {
+			return fmt.Errorf("nil search parameter")
+		}
+		// The canonical URL may carry a "|version" suffix.
+		u, err := url.Parse(p.CanonicalUrl)
+		if err != nil || !u.IsAbs() || u.Host == "" {
+			return fmt.Errorf("invalid search parameter canonical URL %q: must be an absolute URL", p.CanonicalUrl)
+		}
+		if !searchParameterNameRE.MatchString(p.Parameter) {
+			return fmt.Errorf("invalid search parameter resource name %q: want projects/*/locations/*/datasets/*/fhirStores/*/fhir/SearchParameter/*", p.Parameter)
+		}
+	}
+
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.FhirStores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	store := &healthcare.FhirStore{
+		SearchConfig: &healthcare.SearchConfig{
+			SearchParameters: params,
+		},
+	}
+
+	resp, err := storesService.Patch(name, store).UpdateMask("searchConfig").Do()
+	if err != nil {
+		return fmt.Errorf("Patch: %v", err)
+	}
+
+	configJSON, _ := json.MarshalIndent(resp.SearchConfig, "", "  ")
+	fmt.Fprintf(w, "Patched FHIR store %q search config:\n%s\n", resp.Name, configJSON)
+	return nil
+}
+
+// [END healthcare_patch_fhir_store_search_config]
@@ -0,0 +1,74 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_fhir_store_configure_streaming]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// bigQueryDatasetURIRE matches BigQuery dataset URIs of the form
+// "bq://PROJECT_ID.DATASET_ID".
+var bigQueryDatasetURIRE = regexp.MustCompile(`^bq://[a-z0-9:.-]+\.\w+$`)
+
+// configureFHIRStoreStreaming updates (patches) a FHIR store to stream
+// resource changes to the BigQuery dataset bigQueryDatasetURI, which must have
+// the form "bq://PROJECT_ID.DATASET_ID".
+func configureFHIRStoreStreaming(w io.Writer, projectID, location, datasetID, fhirStoreID, bigQueryDatasetURI string) error {
+	if !bigQueryDatasetURIRE.MatchString(bigQueryDatasetURI) {
+		return fmt.Errorf("invalid BigQuery dataset URI %q: want bq://PROJECT_ID.DATASET_ID", bigQueryDatasetURI)
+	}
+
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.FhirStores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", projectID, location, datasetID, fhirStoreID)
+
+	store := &healthcare.FhirStore{
+		StreamConfigs: []*healthcare.StreamConfig{
+			{
+				BigqueryDestination: &healthcare.GoogleCloudHealthcareV1beta1FhirBigQueryDestination{
+					DatasetUri: bigQueryDatasetURI,
+					SchemaConfig: &healthcare.SchemaConfig{
+						SchemaType: "ANALYTICS",
+					},
+				},
+			},
+		},
+	}
+
+	resp, err := storesService.Patch(name, store).UpdateMask("streamConfigs").Do()
+	if err != nil {
+		return fmt.Errorf("Patch: %v", err)
+	}
+
+	configJSON, _ := json.MarshalIndent(resp.StreamConfigs, "", "  ")
+	fmt.Fprintf(w, "Patched FHIR store %q to stream to BigQuery:\n%s\n", resp.Name, configJSON)
+	return nil
+}
+
+// [END healthcare_fhir_store_configure_streaming]
@@ -16,13 +16,18 @@ package snippets
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"strings"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/storage"
 	"github.com/GoogleCloudPlatform/golang-samples/internal/testutil"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/iterator"
 )
 
 // TestFHIRStore runs all FHIR store tests to avoid having to
@@ -53,15 +58,285 @@ func TestFHIRStore(t *testing.T) {
 		}
 	})
 
+	member := "group:dpebot@google.com"
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := setFHIRStoreIamPolicy(buf, tc.ProjectID, location, datasetID, fhirStoreID, "roles/viewer", member); err != nil {
+			r.Errorf("setFHIRStoreIamPolicy got err: %v", err)
+		}
+		if got := buf.String(); !strings.Contains(got, member) {
+			r.Errorf("setFHIRStoreIamPolicy got %q; want to contain %q", got, member)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		metadata, err := getFHIRStoreMetadata(buf, tc.ProjectID, location, datasetID, fhirStoreID)
+		if err != nil {
+			r.Errorf("getFHIRStoreMetadata got err: %v", err)
+			return
+		}
+		if got, wantContain := string(metadata), "CapabilityStatement"; !strings.Contains(got, wantContain) {
+			r.Errorf("getFHIRStoreMetadata got\n----\n%v\n----\nWant to contain:\n----\n%v\n----\n", got, wantContain)
+		}
+		if got, wantContain := buf.String(), "FHIR version"; !strings.Contains(got, wantContain) {
+			r.Errorf("getFHIRStoreMetadata got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		invalid := []byte(`{"resourceType": "Patient", "gender": "unknown-gender"}`)
+		valid, err := validateFHIRResource(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, "Patient", invalid)
+		if err != nil {
+			r.Errorf("validateFHIRResource got err: %v", err)
+			return
+		}
+		if valid {
+			r.Errorf("validateFHIRResource(%s) got valid, want invalid", invalid)
+		}
+	})
+
+	patient := []byte(`{"resourceType": "Patient", "identifier": [{"system": "urn:mrn", "value": "12345"}], "name": [{"family": "Smith"}]}`)
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := conditionalUpdateFHIRResource(buf, tc.ProjectID, location, datasetID, fhirStoreID, "Patient", map[string]string{"identifier": "urn:mrn|12345"}, patient); err != nil {
+			r.Errorf("conditionalUpdateFHIRResource got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "Smith"; !strings.Contains(got, wantContain) {
+			r.Errorf("conditionalUpdateFHIRResource got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		valid, err := validateFHIRResource(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, "Patient", patient)
+		if err != nil {
+			r.Errorf("validateFHIRResource got err: %v", err)
+			return
+		}
+		if !valid {
+			r.Errorf("validateFHIRResource(%s) got invalid, want valid", patient)
+		}
+	})
+
+	var patientResource struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &patientResource); err != nil {
+		t.Errorf("json.Unmarshal conditionalUpdateFHIRResource output got err: %v", err)
+	}
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		bundle, err := getPatientEverything(buf, tc.ProjectID, location, datasetID, fhirStoreID, patientResource.ID)
+		if err != nil {
+			r.Errorf("getPatientEverything got err: %v", err)
+			return
+		}
+		if got, wantContain := string(bundle), "Smith"; !strings.Contains(got, wantContain) {
+			r.Errorf("getPatientEverything got\n----\n%v\n----\nWant to contain:\n----\n%v\n----\n", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		resource, err := getFHIRResourceByIdentifier(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, "Patient", "urn:mrn", "12345")
+		if err != nil {
+			r.Errorf("getFHIRResourceByIdentifier got err: %v", err)
+			return
+		}
+		if got, wantContain := string(resource), "Smith"; !strings.Contains(got, wantContain) {
+			r.Errorf("getFHIRResourceByIdentifier got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	if _, err := getFHIRResourceByIdentifier(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, "Patient", "urn:mrn", "no-such-mrn"); err == nil {
+		t.Errorf("getFHIRResourceByIdentifier with an unknown identifier got nil err, want error")
+	}
+
+	if err := conditionalDeleteFHIRResources(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, "Patient", nil); err == nil {
+		t.Errorf("conditionalDeleteFHIRResources without search parameters got nil err, want error")
+	}
+
+	deidentifiedStoreID := "my-fhir-store-deidentified"
+	if err := createFHIRStore(ioutil.Discard, tc.ProjectID, location, datasetID, deidentifiedStoreID); err != nil {
+		t.Errorf("createFHIRStore (deidentified) got err: %v", err)
+	}
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		destination := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/fhirStores/%s", tc.ProjectID, location, datasetID, deidentifiedStoreID)
+		if err := deidentifyFHIRStore(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, destination, nil); err != nil {
+			r.Errorf("deidentifyFHIRStore got err: %v", err)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := conditionalDeleteFHIRResources(buf, tc.ProjectID, location, datasetID, fhirStoreID, "Patient", map[string]string{"identifier": "urn:mrn|12345"}); err != nil {
+			r.Errorf("conditionalDeleteFHIRResources got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "1 matched before the delete"; !strings.Contains(got, wantContain) {
+			r.Errorf("conditionalDeleteFHIRResources got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	var seededIDs []string
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		var patients [][]byte
+		for i := 0; i <= seedBundleThreshold; i++ {
+			patients = append(patients, []byte(fmt.Sprintf(`{"resourceType": "Patient", "name": [{"family": "Seed%d"}]}`, i)))
+		}
+		var err error
+		seededIDs, err = seedFHIRResources(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, patients)
+		if err != nil {
+			r.Errorf("seedFHIRResources got err: %v", err)
+			return
+		}
+		for i, id := range seededIDs {
+			if id == "" {
+				r.Errorf("seedFHIRResources got empty ID for resource %d", i)
+			}
+		}
+	})
+
+	// Observe a seeded Patient so that the Patient deleted above isn't referenced.
+	observedPatientID := "missing-patient"
+	if len(seededIDs) > 0 {
+		observedPatientID = seededIDs[0]
+	}
+	if err := createObservation(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, "Patient/"+observedPatientID, "8867-4", 72, "/min"); err == nil {
+		t.Errorf("createObservation with a Patient reference instead of an ID got nil err, want error")
+	}
+
+	// Everything written from here until the rollback below is undone by it.
+	rollbackTime := time.Now()
+	time.Sleep(time.Second)
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := createObservation(buf, tc.ProjectID, location, datasetID, fhirStoreID, observedPatientID, "8867-4", 72, "/min"); err != nil {
+			r.Errorf("createObservation got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "Created Observation"; !strings.Contains(got, wantContain) {
+			r.Errorf("createObservation got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	if err := rollbackFHIRStore(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, "gs://my-bucket", time.Now().Add(time.Hour)); err == nil {
+		t.Errorf("rollbackFHIRStore to a future time got nil err, want error")
+	}
+	if err := rollbackFHIRStore(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, "my-bucket", rollbackTime); err == nil {
+		t.Errorf("rollbackFHIRStore with a bucket name instead of a gs:// URI got nil err, want error")
+	}
+
+	ctx := context.Background()
+	storageClient, err := storage.NewClient(ctx)
+	if err != nil {
+		t.Fatalf("storage.NewClient: %v", err)
+	}
+	defer storageClient.Close()
+	resultBucket := fmt.Sprintf("%s-fhir-rollback-%d", tc.ProjectID, time.Now().Unix())
+	if err := storageClient.Bucket(resultBucket).Create(ctx, tc.ProjectID, nil); err != nil {
+		t.Fatalf("Create(%q): %v", resultBucket, err)
+	}
+	defer deleteTestBucket(t, storageClient, resultBucket)
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := rollbackFHIRStore(buf, tc.ProjectID, location, datasetID, fhirStoreID, "gs://"+resultBucket, rollbackTime); err != nil {
+			r.Errorf("rollbackFHIRStore got err: %v", err)
+			return
+		}
+		// The Observation created after rollbackTime is rolled back.
+		if got := buf.String(); !strings.Contains(got, "Rolled back") || strings.Contains(got, "succeeded: 0,") {
+			r.Errorf("rollbackFHIRStore got %q; want at least one resource rolled back", got)
+		}
+	})
+
+	if err := copyFHIRStore(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, tc.ProjectID, location, datasetID, fhirStoreID, "my-bucket"); err == nil {
+		t.Errorf("copyFHIRStore onto itself got nil err, want error")
+	}
+
+	if err := exportFHIRResourcesIncremental(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, "gs://my-bucket/", "yesterday"); err == nil {
+		t.Errorf("exportFHIRResourcesIncremental with a non-RFC3339 timestamp got nil err, want error")
+	}
+
+	badParams := []*healthcare.SearchParameter{{CanonicalUrl: "http://example.com/SearchParameter/eye-color", Parameter: "SearchParameter/eye-color"}}
+	if err := patchFHIRStoreSearchConfig(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, badParams); err == nil {
+		t.Errorf("patchFHIRStoreSearchConfig with a relative parameter name got nil err, want error")
+	}
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := getFHIRStoreSearchConfig(buf, tc.ProjectID, location, datasetID, fhirStoreID); err != nil {
+			r.Errorf("getFHIRStoreSearchConfig got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "no custom search parameters"; !strings.Contains(got, wantContain) {
+			r.Errorf("getFHIRStoreSearchConfig got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	if err := configureFHIRStoreStreaming(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID, "my-dataset"); err == nil {
+		t.Errorf("configureFHIRStoreStreaming with an invalid BigQuery URI got nil err, want error")
+	}
+
+	strictStoreID := "my-fhir-store-strict"
+	if err := createFHIRStoreWithSearchHandling(ioutil.Discard, tc.ProjectID, location, datasetID, strictStoreID, false, false); err == nil {
+		t.Errorf("createFHIRStoreWithSearchHandling with no options got nil err, want error")
+	}
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := createFHIRStoreWithSearchHandling(buf, tc.ProjectID, location, datasetID, strictStoreID, true, false); err != nil {
+			r.Errorf("createFHIRStoreWithSearchHandling got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "Strict search handling: true"; !strings.Contains(got, wantContain) {
+			r.Errorf("createFHIRStoreWithSearchHandling got %q; want to contain %q", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := deleteFHIRStore(ioutil.Discard, tc.ProjectID, location, datasetID, strictStoreID); err != nil {
+			r.Errorf("deleteFHIRStore (strict) got err: %v", err)
+		}
+	})
+
 	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
 		if err := deleteFHIRStore(ioutil.Discard, tc.ProjectID, location, datasetID, fhirStoreID); err != nil {
 			r.Errorf("deleteFHIRStore got err: %v", err)
 		}
 	})
 
+	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
+		if err := deleteFHIRStore(ioutil.Discard, tc.ProjectID, location, datasetID, deidentifiedStoreID); err != nil {
+			r.Errorf("deleteFHIRStore (deidentified) got err: %v", err)
+		}
+	})
+
 	testutil.Retry(t, 10, 2*time.Second, func(r *testutil.R) {
 		if err := deleteDataset(ioutil.Discard, tc.ProjectID, location, datasetID); err != nil {
 			r.Errorf("deleteDataset got err: %v", err)
 		}
 	})
 }
+
+// deleteTestBucket deletes the bucket name and every object in it.
+func deleteTestBucket(t *testing.T, client *storage.Client, name string) {
+	ctx := context.Background()
+	bucket := client.Bucket(name)
+	it := bucket.Objects(ctx, nil)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Errorf("Objects(%q): %v", name, err)
+			return
+		}
+		if err := bucket.Object(attrs.Name).Delete(ctx); err != nil {
+			t.Errorf("Delete(%q): %v", attrs.Name, err)
+		}
+	}
+	if err := bucket.Delete(ctx); err != nil {
+		t.Errorf("Delete(%q): %v", name, err)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_batch_get_hl7v2_messages]
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// batchGetHL7V2Messages gets every HL7V2 message matching filter, such as
+// `labels.routing="lab-results"`, fetching up to concurrency messages at a
+// time. Messages that can't be fetched are left nil in the result and their
+// errors are combined into the returned error.
+func batchGetHL7V2Messages(ctx context.Context, w io.Writer, projectID, location, datasetID, hl7V2StoreID, filter string, concurrency int) ([]*healthcare.Message, error) {
+	if concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be at least 1, got %d", concurrency)
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	messagesService := healthcareService.Projects.Locations.Datasets.Hl7V2Stores.Messages
+
+	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/hl7V2Stores/%s", projectID, location, datasetID, hl7V2StoreID)
+
+	var names []string
+	err = messagesService.List(parent).Filter(filter).Pages(ctx, func(resp *healthcare.ListMessagesResponse) error {
+		names = append(names, resp.Messages...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("List: %v", err)
+	}
+
+	messages := make([]*healthcare.Message, len(names))
+	errs := make([]error, len(names))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				m, err := messagesService.Get(names[i]).Context(ctx).Do()
+				if err != nil {
+					errs[i] = fmt.Errorf("Get(%q): %v", names[i], err)
+					continue
+				}
+				messages[i] = m
+			}
+		}()
+	}
+
+send:
+	for i := range names {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return messages, err
+	}
+
+	var errMsgs []string
+	for _, err := range errs {
+		if err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+
+	fmt.Fprintf(w, "Got %d of %d HL7V2 messages\n", len(names)-len(errMsgs), len(names))
+	if len(errMsgs) > 0 {
+		return messages, fmt.Errorf("failed to get %d messages: %s", len(errMsgs), strings.Join(errMsgs, "; "))
+	}
+	return messages, nil
+}
+
+// [END healthcare_batch_get_hl7v2_messages]
@@ -19,7 +19,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 
+	"google.golang.org/api/googleapi"
 	healthcare "google.golang.org/api/healthcare/v1beta1"
 )
 
@@ -36,6 +38,9 @@ func deleteHL7V2Message(w io.Writer, projectID, location, datasetID, hl7V2StoreI
 
 	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/hl7V2Stores/%s/messages/%s", projectID, location, datasetID, hl7V2StoreID, hl7V2MessageID)
 	if _, err := messagesService.Delete(name).Do(); err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return fmt.Errorf("Delete: HL7V2 message %q not found: %v", name, err)
+		}
 		return fmt.Errorf("Delete: %v", err)
 	}
 
@@ -0,0 +1,77 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_get_hl7v2_message_schematized]
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// getHL7V2MessageWithSchematizedData gets an HL7V2 message using the FULL view,
+// which includes the structured data produced by a schematized ParserConfig.
+// If the store isn't schematized, the raw message is printed instead.
+func getHL7V2MessageWithSchematizedData(w io.Writer, projectID, location, datasetID, hl7V2StoreID, hl7V2MessageID string) error {
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	messagesService := healthcareService.Projects.Locations.Datasets.Hl7V2Stores.Messages
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/hl7V2Stores/%s/messages/%s", projectID, location, datasetID, hl7V2StoreID, hl7V2MessageID)
+	message, err := messagesService.Get(name).View("FULL").Do()
+	if err != nil {
+		return fmt.Errorf("Get: %v", err)
+	}
+
+	fmt.Fprintf(w, "Got HL7V2 message.\n")
+	fmt.Fprintf(w, "Message type: %s\n", message.MessageType)
+	fmt.Fprintf(w, "Sending facility: %s\n", message.SendFacility)
+	fmt.Fprintf(w, "Send time: %s\n", message.SendTime)
+	for _, id := range message.PatientIds {
+		fmt.Fprintf(w, "Patient ID: %s (%s)\n", id.Value, id.Type)
+	}
+
+	if sd := message.SchematizedData; sd != nil && sd.Data != "" {
+		if sd.Error != "" {
+			fmt.Fprintf(w, "Schematization error: %s\n", sd.Error)
+		}
+		var data interface{}
+		if err := json.Unmarshal([]byte(sd.Data), &data); err != nil {
+			return fmt.Errorf("json.Unmarshal: %v", err)
+		}
+		schematizedJSON, _ := json.MarshalIndent(data, "", "  ")
+		fmt.Fprintf(w, "Schematized data:\n%s\n", schematizedJSON)
+		return nil
+	}
+
+	// The store has no schematized parser, so fall back to the raw message.
+	rawData, err := base64.StdEncoding.DecodeString(message.Data)
+	if err != nil {
+		return fmt.Errorf("base64.DecodeString: %v", err)
+	}
+	fmt.Fprintf(w, "No schematized data; raw message:\n%s\n", rawData)
+	return nil
+}
+
+// [END healthcare_get_hl7v2_message_schematized]
@@ -0,0 +1,104 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_export_hl7v2_messages]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// exportHL7V2Messages exports the messages in an HL7V2 store to GCS.
+// gcsURIPrefix has the form "gs://my-bucket/path/to/prefix/". If startTime or
+// endTime is not zero, only messages sent at or after startTime and before
+// endTime are exported.
+func exportHL7V2Messages(w io.Writer, projectID, location, datasetID, hl7V2StoreID, gcsURIPrefix string, startTime, endTime time.Time) error {
+	return exportHL7V2MessagesWithContext(context.Background(), w, projectID, location, datasetID, hl7V2StoreID, gcsURIPrefix, startTime, endTime)
+}
+
+// exportHL7V2MessagesWithContext is like exportHL7V2Messages but uses ctx for
+// its API calls, including polling the operation.
+func exportHL7V2MessagesWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, hl7V2StoreID, gcsURIPrefix string, startTime, endTime time.Time) error {
+	if !strings.HasPrefix(gcsURIPrefix, "gs://") {
+		return fmt.Errorf("invalid GCS URI prefix %q: must start with gs://", gcsURIPrefix)
+	}
+	if !startTime.IsZero() && !endTime.IsZero() && !startTime.Before(endTime) {
+		return fmt.Errorf("start time %v must be before end time %v", startTime, endTime)
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.Hl7V2Stores
+
+	req := &healthcare.ExportMessagesRequest{
+		GcsDestination: &healthcare.GcsDestination{
+			UriPrefix: gcsURIPrefix,
+		},
+	}
+	if !startTime.IsZero() {
+		req.StartTime = startTime.UTC().Format(time.RFC3339Nano)
+	}
+	if !endTime.IsZero() {
+		req.EndTime = endTime.UTC().Format(time.RFC3339Nano)
+	}
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/hl7V2Stores/%s", projectID, location, datasetID, hl7V2StoreID)
+
+	lro, err := storesService.Export(name, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Export: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("export: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("export: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+	var metadata healthcare.OperationMetadata
+	if err := json.Unmarshal(op.Metadata, &metadata); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+	if metadata.Counter == nil {
+		metadata.Counter = &healthcare.ProgressCounter{}
+	}
+	fmt.Fprintf(w, "Exported HL7V2 messages to %s (succeeded: %d, failed: %d)\n", gcsURIPrefix, metadata.Counter.Success, metadata.Counter.Failure)
+	return nil
+}
+
+// [END healthcare_export_hl7v2_messages]
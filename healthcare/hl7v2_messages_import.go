@@ -0,0 +1,97 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_import_hl7v2_messages]
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// importHL7V2Messages imports HL7V2 messages from GCS into an HL7V2 store and
+// reports how many were ingested and how many failed to parse.
+// gcsSourceURI has the form "gs://my-bucket/path/to/messages/*.ndjson".
+func importHL7V2Messages(w io.Writer, projectID, location, datasetID, hl7V2StoreID, gcsSourceURI string) error {
+	return importHL7V2MessagesWithContext(context.Background(), w, projectID, location, datasetID, hl7V2StoreID, gcsSourceURI)
+}
+
+// importHL7V2MessagesWithContext is like importHL7V2Messages but uses ctx for
+// its API calls, including polling the operation.
+func importHL7V2MessagesWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, hl7V2StoreID, gcsSourceURI string) error {
+	if !strings.HasPrefix(gcsSourceURI, "gs://") {
+		return fmt.Errorf("invalid GCS source URI %q: must start with gs://", gcsSourceURI)
+	}
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.Hl7V2Stores
+
+	req := &healthcare.ImportMessagesRequest{
+		GcsSource: &healthcare.GcsSource{
+			Uri: gcsSourceURI,
+		},
+	}
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/hl7V2Stores/%s", projectID, location, datasetID, hl7V2StoreID)
+
+	lro, err := storesService.Import(name, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("Import: %v", err)
+	}
+
+	// Poll the operation until it is done, backing off up to 30 seconds
+	// between polls and giving up after 30 minutes.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	op := lro
+	for delay := time.Second; !op.Done; {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("import: operation %q did not finish: %v", lro.Name, ctx.Err())
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		if op, err = healthcareService.Projects.Locations.Datasets.Operations.Get(lro.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("Operations.Get: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("import: operation %q failed: %s", op.Name, op.Error.Message)
+	}
+	var metadata healthcare.OperationMetadata
+	if err := json.Unmarshal(op.Metadata, &metadata); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+	if metadata.Counter == nil {
+		metadata.Counter = &healthcare.ProgressCounter{}
+	}
+	fmt.Fprintf(w, "Imported HL7V2 messages from %s (ingested: %d, failed: %d)\n", gcsSourceURI, metadata.Counter.Success, metadata.Counter.Failure)
+	if metadata.Counter.Failure > 0 && metadata.LogsUrl != "" {
+		fmt.Fprintf(w, "Parse failures are logged at %s\n", metadata.LogsUrl)
+	}
+	return nil
+}
+
+// [END healthcare_import_hl7v2_messages]
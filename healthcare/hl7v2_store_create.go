@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // createHL7V2Store creates an HL7V2 store.
 func createHL7V2Store(w io.Writer, projectID, location, datasetID, hl7V2StoreID string) error {
-	ctx := context.Background()
+	return createHL7V2StoreWithContext(context.Background(), w, projectID, location, datasetID, hl7V2StoreID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// createHL7V2StoreWithContext is like createHL7V2Store but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func createHL7V2StoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, hl7V2StoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -37,7 +42,7 @@ func createHL7V2Store(w io.Writer, projectID, location, datasetID, hl7V2StoreID
 	store := &healthcare.Hl7V2Store{}
 	parent := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
 
-	resp, err := storesService.Create(parent, store).Hl7V2StoreId(hl7V2StoreID).Do()
+	resp, err := storesService.Create(parent, store).Hl7V2StoreId(hl7V2StoreID).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("Create: %v", err)
 	}
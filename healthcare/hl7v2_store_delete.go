@@ -21,13 +21,19 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // deleteHL7V2Store deletes an HL7V2 store.
 func deleteHL7V2Store(w io.Writer, projectID, location, datasetID, hl7V2StoreID string) error {
-	ctx := context.Background()
+	return deleteHL7V2StoreWithContext(context.Background(), w, projectID, location, datasetID, hl7V2StoreID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// deleteHL7V2StoreWithContext is like deleteHL7V2Store but uses ctx for its API
+// calls, so the caller can bound how long they take or cancel them. opts
+// configure the service, for example its credentials or endpoint.
+func deleteHL7V2StoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, hl7V2StoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -36,7 +42,7 @@ func deleteHL7V2Store(w io.Writer, projectID, location, datasetID, hl7V2StoreID
 
 	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/hl7V2Stores/%s", projectID, location, datasetID, hl7V2StoreID)
 
-	if _, err := storesService.Delete(name).Do(); err != nil {
+	if _, err := storesService.Delete(name).Context(ctx).Do(); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
 
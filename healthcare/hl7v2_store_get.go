@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // getHL7V2Store gets an HL7V2 store.
 func getHL7V2Store(w io.Writer, projectID, location, datasetID, hl7v2StoreID string) error {
-	ctx := context.Background()
+	return getHL7V2StoreWithContext(context.Background(), w, projectID, location, datasetID, hl7v2StoreID)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// getHL7V2StoreWithContext is like getHL7V2Store but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func getHL7V2StoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, hl7v2StoreID string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -36,7 +41,7 @@ func getHL7V2Store(w io.Writer, projectID, location, datasetID, hl7v2StoreID str
 
 	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/hl7v2Stores/%s", projectID, location, datasetID, hl7v2StoreID)
 
-	store, err := storesService.Get(name).Do()
+	store, err := storesService.Get(name).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("Get: %v", err)
 	}
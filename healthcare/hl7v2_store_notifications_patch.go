@@ -0,0 +1,71 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+// [START healthcare_patch_hl7v2_store_notifications]
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+// pubsubTopicRE matches a full Pub/Sub topic resource name.
+var pubsubTopicRE = regexp.MustCompile(`^projects/[^/]+/topics/[^/]+$`)
+
+// patchHL7V2StoreNotifications replaces the notification configs of an HL7V2
+// store. Each config routes the messages matching its filter to its own
+// Pub/Sub topic, for example:
+//
+//	[]*healthcare.Hl7V2NotificationConfig{
+//		{Filter: `messageType = "ADT"`, PubsubTopic: "projects/my-project/topics/adt"},
+//		{Filter: `messageType = "ORU"`, PubsubTopic: "projects/my-project/topics/oru"},
+//	}
+func patchHL7V2StoreNotifications(w io.Writer, projectID, location, datasetID, hl7V2StoreID string, configs []*healthcare.Hl7V2NotificationConfig) error {
+	for _, c := range configs {
+		if c == nil || !pubsubTopicRE.MatchString(c.PubsubTopic) {
+			return fmt.Errorf("invalid notification config %+v: PubsubTopic must have the form projects/*/topics/*", c)
+		}
+	}
+
+	ctx := context.Background()
+
+	healthcareService, err := healthcare.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("healthcare.New: %v", err)
+	}
+
+	storesService := healthcareService.Projects.Locations.Datasets.Hl7V2Stores
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s/hl7V2Stores/%s", projectID, location, datasetID, hl7V2StoreID)
+
+	store, err := storesService.Patch(name, &healthcare.Hl7V2Store{
+		NotificationConfigs: configs,
+	}).UpdateMask("notificationConfigs").Do()
+	if err != nil {
+		return fmt.Errorf("Patch: %v", err)
+	}
+
+	fmt.Fprintf(w, "Patched HL7V2 store %s with %d notification config(s):\n", hl7V2StoreID, len(store.NotificationConfigs))
+	for _, c := range store.NotificationConfigs {
+		fmt.Fprintf(w, "  %q -> %s\n", c.Filter, c.PubsubTopic)
+	}
+
+	return nil
+}
+
+// [END healthcare_patch_hl7v2_store_notifications]
@@ -21,13 +21,18 @@ import (
 	"io"
 
 	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
 )
 
 // patchHL7V2Store updates (patches) a HL7V2 store by updating its Pub/sub topic name.
 func patchHL7V2Store(w io.Writer, projectID, location, datasetID, hl7v2StoreID, topicName string) error {
-	ctx := context.Background()
+	return patchHL7V2StoreWithContext(context.Background(), w, projectID, location, datasetID, hl7v2StoreID, topicName)
+}
 
-	healthcareService, err := healthcare.NewService(ctx)
+// patchHL7V2StoreWithContext is like patchHL7V2Store but uses ctx for its API
+// calls. opts configure the service, for example its credentials or endpoint.
+func patchHL7V2StoreWithContext(ctx context.Context, w io.Writer, projectID, location, datasetID, hl7v2StoreID, topicName string, opts ...option.ClientOption) error {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("healthcare.New: %v", err)
 	}
@@ -40,7 +45,7 @@ func patchHL7V2Store(w io.Writer, projectID, location, datasetID, hl7v2StoreID,
 		NotificationConfig: &healthcare.NotificationConfig{
 			PubsubTopic: topicName, // format is "projects/*/locations/*/topics/*"
 		},
-	}).UpdateMask("notificationConfig").Do(); err != nil {
+	}).UpdateMask("notificationConfig").Context(ctx).Do(); err != nil {
 		return fmt.Errorf("Patch: %v", err)
 	}
 
@@ -16,6 +16,7 @@ package snippets
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"strings"
@@ -23,6 +24,7 @@ import (
 	"time"
 
 	"github.com/GoogleCloudPlatform/golang-samples/internal/testutil"
+	healthcare "google.golang.org/api/healthcare/v1beta1"
 )
 
 // TestHL7V2Store runs all HL7V2 store tests to avoid having to
@@ -52,6 +54,23 @@ func TestHL7V2Store(t *testing.T) {
 		}
 	})
 
+	member := "group:dpebot@google.com"
+	testutil.Retry(t, 10, time.Second, func(r *testutil.R) {
+		if err := getHL7V2StoreIamPolicy(ioutil.Discard, tc.ProjectID, location, datasetID, hl7V2StoreID); err != nil {
+			r.Errorf("getHL7V2StoreIamPolicy got err: %v", err)
+		}
+	})
+
+	testutil.Retry(t, 10, time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := setHL7V2StoreIamPolicy(buf, tc.ProjectID, location, datasetID, hl7V2StoreID, "roles/viewer", member); err != nil {
+			r.Errorf("setHL7V2StoreIamPolicy got err: %v", err)
+		}
+		if got := buf.String(); !strings.Contains(got, member) {
+			r.Errorf("setHL7V2StoreIamPolicy got %q; want to contain %q", got, member)
+		}
+	})
+
 	messageID := "2yqbdhYHlk_ucSmWkcKOVm_N0p0OpBXgIlVG18rB-cw=" // TODO(cbro): use return value from create. seems to be stable though.
 
 	dataFile := "testdata/hl7v2message.dat" // size = 167 bytes
@@ -76,6 +95,51 @@ func TestHL7V2Store(t *testing.T) {
 		}
 	})
 
+	testutil.Retry(t, 10, time.Second, func(r *testutil.R) {
+		buf.Reset()
+		if err := getHL7V2MessageWithSchematizedData(buf, tc.ProjectID, location, datasetID, hl7V2StoreID, messageID); err != nil {
+			r.Errorf("getHL7V2MessageWithSchematizedData got err: %v", err)
+		}
+		// The test store isn't schematized, so the raw message is printed.
+		if got, wantContain := buf.String(), "raw message"; !strings.Contains(got, wantContain) {
+			r.Errorf("getHL7V2MessageWithSchematizedData got\n----\n%v\n----\nWant to contain:\n----\n%v\n----\n", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, time.Second, func(r *testutil.R) {
+		buf.Reset()
+		labels := map[string]string{"routing": "lab-results"}
+		if err := patchHL7V2Message(buf, tc.ProjectID, location, datasetID, hl7V2StoreID, messageID, labels); err != nil {
+			r.Errorf("patchHL7V2Message got err: %v", err)
+		}
+		if got, wantContain := buf.String(), "lab-results"; !strings.Contains(got, wantContain) {
+			r.Errorf("patchHL7V2Message got\n----\n%v\n----\nWant to contain:\n----\n%v\n----\n", got, wantContain)
+		}
+	})
+
+	testutil.Retry(t, 10, time.Second, func(r *testutil.R) {
+		messages, err := batchGetHL7V2Messages(context.Background(), ioutil.Discard, tc.ProjectID, location, datasetID, hl7V2StoreID, `labels.routing="lab-results"`, 2)
+		if err != nil {
+			r.Errorf("batchGetHL7V2Messages got err: %v", err)
+		}
+		if len(messages) != 1 {
+			r.Errorf("batchGetHL7V2Messages got %d messages, want 1", len(messages))
+		}
+	})
+
+	badConfigs := []*healthcare.Hl7V2NotificationConfig{{Filter: `messageType = "ADT"`, PubsubTopic: "adt-topic"}}
+	if err := patchHL7V2StoreNotifications(ioutil.Discard, tc.ProjectID, location, datasetID, hl7V2StoreID, badConfigs); err == nil {
+		t.Errorf("patchHL7V2StoreNotifications with a short topic name got nil err, want error")
+	}
+
+	if err := importHL7V2Messages(ioutil.Discard, tc.ProjectID, location, datasetID, hl7V2StoreID, "https://storage.googleapis.com/my-bucket/messages.ndjson"); err == nil {
+		t.Errorf("importHL7V2Messages without a gs:// URI got nil err, want error")
+	}
+
+	if err := exportHL7V2Messages(ioutil.Discard, tc.ProjectID, location, datasetID, hl7V2StoreID, "my-bucket/messages/", time.Time{}, time.Time{}); err == nil {
+		t.Errorf("exportHL7V2Messages without a gs:// prefix got nil err, want error")
+	}
+
 	testutil.Retry(t, 10, time.Second, func(r *testutil.R) {
 		if err := deleteHL7V2Message(ioutil.Discard, tc.ProjectID, location, datasetID, hl7V2StoreID, messageID); err != nil {
 			r.Errorf("deleteHL7V2Message got err: %v", err)
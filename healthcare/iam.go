@@ -0,0 +1,43 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import healthcare "google.golang.org/api/healthcare/v1beta1"
+
+// addIamBinding grants role to member in policy, reusing an existing binding
+// for role if there is one. It reports whether policy was changed.
+//
+// policy keeps the Etag returned by GetIamPolicy. Sending it back with
+// SetIamPolicy makes the update fail, rather than overwrite the policy, if
+// the policy was changed concurrently.
+func addIamBinding(policy *healthcare.Policy, role, member string) bool {
+	for _, b := range policy.Bindings {
+		if b.Role != role {
+			continue
+		}
+		for _, m := range b.Members {
+			if m == member {
+				return false
+			}
+		}
+		b.Members = append(b.Members, member)
+		return true
+	}
+	policy.Bindings = append(policy.Bindings, &healthcare.Binding{
+		Role:    role,
+		Members: []string{member},
+	})
+	return true
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import (
+	"testing"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+)
+
+func TestAddIamBinding(t *testing.T) {
+	const (
+		role   = "roles/viewer"
+		member = "group:dpebot@google.com"
+	)
+	policy := &healthcare.Policy{
+		Bindings: []*healthcare.Binding{
+			{Role: "roles/editor", Members: []string{"user:a@example.com"}},
+		},
+		Etag: "BwWKmjvelug=",
+	}
+
+	if changed := addIamBinding(policy, role, member); !changed {
+		t.Errorf("addIamBinding(%q, %q) on a new role got changed=false, want true", role, member)
+	}
+	if changed := addIamBinding(policy, role, member); changed {
+		t.Errorf("addIamBinding(%q, %q) on an existing binding got changed=true, want false", role, member)
+	}
+	addIamBinding(policy, role, "user:b@example.com")
+
+	if got, want := len(policy.Bindings), 2; got != want {
+		t.Fatalf("got %d bindings, want %d", got, want)
+	}
+	b := policy.Bindings[1]
+	if b.Role != role {
+		t.Errorf("got role %q, want %q", b.Role, role)
+	}
+	if got, want := len(b.Members), 2; got != want {
+		t.Errorf("got members %v, want %d members", b.Members, want)
+	}
+	if policy.Etag != "BwWKmjvelug=" {
+		t.Errorf("addIamBinding changed the policy Etag to %q", policy.Etag)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+// recordingServer is a fake Healthcare API that records each request it
+// receives and responds with an empty JSON object. The first unavailable
+// requests are rejected with 503 Service Unavailable instead.
+type recordingServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	requests    []string
+	unavailable int
+}
+
+// newRecordingServer starts a recordingServer. Pass its options to a sample
+// to send the sample's requests to it. The caller must Close it.
+func newRecordingServer() *recordingServer {
+	s := &recordingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		unavailable := len(s.requests) <= s.unavailable
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if unavailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error": {"code": 503, "message": "unavailable"}}`)
+			return
+		}
+		fmt.Fprint(w, "{}")
+	}))
+	return s
+}
+
+// options returns the client options that point a healthcare.Service at s.
+// The server's own client sends the requests, so no credentials are needed.
+func (s *recordingServer) options() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(s.URL + "/"),
+		option.WithHTTPClient(s.Client()),
+	}
+}
+
+func TestHelpersAgainstRecordingServer(t *testing.T) {
+	const (
+		projectID      = "my-project"
+		location       = "us-central1"
+		datasetID      = "my-dataset"
+		consentStoreID = "my-consent-store"
+	)
+	datasetPath := "/v1beta1/projects/my-project/locations/us-central1/datasets/my-dataset"
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func(opts []option.ClientOption) error
+		want string
+	}{
+		{
+			name: "createDataset",
+			call: func(opts []option.ClientOption) error {
+				return createDatasetWithContext(ctx, ioutil.Discard, projectID, location, datasetID, opts...)
+			},
+			want: "POST /v1beta1/projects/my-project/locations/us-central1/datasets",
+		},
+		{
+			name: "getDataset",
+			call: func(opts []option.ClientOption) error {
+				return getDatasetWithContext(ctx, ioutil.Discard, projectID, location, datasetID, opts...)
+			},
+			want: "GET " + datasetPath,
+		},
+		{
+			name: "deleteDataset",
+			call: func(opts []option.ClientOption) error {
+				return deleteDatasetWithContext(ctx, ioutil.Discard, projectID, location, datasetID, opts...)
+			},
+			want: "DELETE " + datasetPath,
+		},
+		{
+			name: "getDICOMStore",
+			call: func(opts []option.ClientOption) error {
+				return getDICOMStoreWithContext(ctx, ioutil.Discard, projectID, location, datasetID, "my-dicom-store", opts...)
+			},
+			want: "GET " + datasetPath + "/dicomStores/my-dicom-store",
+		},
+		{
+			name: "listFHIRStores",
+			call: func(opts []option.ClientOption) error {
+				return listFHIRStoresWithContext(ctx, ioutil.Discard, projectID, location, datasetID, opts...)
+			},
+			want: "GET " + datasetPath + "/fhirStores",
+		},
+		{
+			name: "deleteHL7V2Store",
+			call: func(opts []option.ClientOption) error {
+				return deleteHL7V2StoreWithContext(ctx, ioutil.Discard, projectID, location, datasetID, "my-hl7v2-store", opts...)
+			},
+			want: "DELETE " + datasetPath + "/hl7V2Stores/my-hl7v2-store",
+		},
+		{
+			name: "createConsentStore",
+			call: func(opts []option.ClientOption) error {
+				return createConsentStoreWithContext(ctx, ioutil.Discard, projectID, location, datasetID, consentStoreID, "", false, opts...)
+			},
+			want: "POST " + datasetPath + "/consentStores",
+		},
+		{
+			name: "deleteConsentStore",
+			call: func(opts []option.ClientOption) error {
+				return deleteConsentStoreWithContext(ctx, ioutil.Discard, projectID, location, datasetID, consentStoreID, opts...)
+			},
+			want: "DELETE " + datasetPath + "/consentStores/my-consent-store",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newRecordingServer()
+			defer srv.Close()
+
+			if err := tc.call(srv.options()); err != nil {
+				t.Fatalf("got err: %v", err)
+			}
+			if len(srv.requests) != 1 || srv.requests[0] != tc.want {
+				t.Errorf("sent %q; want [%q]", srv.requests, tc.want)
+			}
+		})
+	}
+}
+
+func TestHelpersHonorContext(t *testing.T) {
+	srv := newRecordingServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := deleteDatasetWithContext(ctx, ioutil.Discard, "my-project", "us-central1", "my-dataset", srv.options()...); err == nil {
+		t.Errorf("deleteDatasetWithContext with a cancelled context got nil err, want error")
+	}
+	if err := getFHIRStoreWithContext(ctx, ioutil.Discard, "my-project", "us-central1", "my-dataset", "my-fhir-store", srv.options()...); err == nil {
+		t.Errorf("getFHIRStoreWithContext with a cancelled context got nil err, want error")
+	}
+	if len(srv.requests) != 0 {
+		t.Errorf("helpers with a cancelled context sent %q; want no requests", srv.requests)
+	}
+}
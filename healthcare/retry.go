@@ -0,0 +1,113 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// defaultRetryAttempts is the number of attempts the samples make before
+// giving up on a transient error.
+const defaultRetryAttempts = 5
+
+// retryBaseDelay is the delay before the first retry. It doubles after each
+// attempt. Tests shorten it.
+var retryBaseDelay = time.Second
+
+// retryableCodes are the HTTP status codes of transient errors.
+var retryableCodes = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusServiceUnavailable:  true,
+}
+
+// doWithRetry calls call until it succeeds, it returns an error that isn't
+// transient, or maxAttempts attempts have been made. An error is transient if
+// it is a *googleapi.Error with one of retryableCodes, so call should return
+// the API's error unwrapped. Between attempts it waits as long as the
+// server's Retry-After header asks, or else with exponential backoff. It
+// returns the last error from call, or ctx's error if ctx is done while
+// waiting.
+func doWithRetry(ctx context.Context, maxAttempts int, call func() error) error {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil {
+			return nil
+		}
+		gerr, ok := err.(*googleapi.Error)
+		if !ok || !retryableCodes[gerr.Code] || attempt >= maxAttempts {
+			return err
+		}
+		wait := delay
+		if d, ok := retryAfter(gerr.Header); ok {
+			wait = d
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// retryAfter parses the Retry-After header in h, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// deleteDatasetWithRetry is like deleteDatasetWithContext but retries
+// transient errors. opts are passed to newHealthcareService.
+func deleteDatasetWithRetry(ctx context.Context, w io.Writer, projectID, location, datasetID string, opts ...option.ClientOption) error {
+	healthcareService, err := newHealthcareService(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("projects/%s/locations/%s/datasets/%s", projectID, location, datasetID)
+	err = doWithRetry(ctx, defaultRetryAttempts, func() error {
+		_, err := healthcareService.Projects.Locations.Datasets.Delete(name).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Delete: %v", err)
+	}
+
+	fmt.Fprintf(w, "Deleted dataset: %q\n", name)
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestDoWithRetry(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "success",
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name:      "transient then success",
+			errs:      []error{&googleapi.Error{Code: http.StatusTooManyRequests}, &googleapi.Error{Code: http.StatusServiceUnavailable}, nil},
+			wantCalls: 3,
+		},
+		{
+			name:      "not found fails fast",
+			errs:      []error{&googleapi.Error{Code: http.StatusNotFound}, nil},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "other errors fail fast",
+			errs:      []error{errors.New("connection reset"), nil},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "attempts exhausted",
+			errs:      []error{&googleapi.Error{Code: http.StatusInternalServerError}, &googleapi.Error{Code: http.StatusInternalServerError}, &googleapi.Error{Code: http.StatusInternalServerError}, nil},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		calls := 0
+		err := doWithRetry(context.Background(), 3, func() error {
+			err := tt.errs[calls]
+			calls++
+			return err
+		})
+		if calls != tt.wantCalls {
+			t.Errorf("%s: doWithRetry made %d calls, want %d", tt.name, calls, tt.wantCalls)
+		}
+		if gotErr := err != nil; gotErr != tt.wantErr {
+			t.Errorf("%s: doWithRetry got err %v, want error: %t", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDoWithRetryHonorsContext(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := doWithRetry(ctx, 3, func() error {
+		calls++
+		cancel()
+		return &googleapi.Error{Code: http.StatusServiceUnavailable}
+	})
+	if err != context.Canceled {
+		t.Errorf("doWithRetry got err %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("doWithRetry made %d calls, want 1", calls)
+	}
+}
+
+func TestDeleteDatasetWithRetry(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+
+	srv := newRecordingServer()
+	defer srv.Close()
+	srv.unavailable = 2
+
+	if err := deleteDatasetWithRetry(context.Background(), ioutil.Discard, "my-project", "us-central1", "my-dataset", srv.options()...); err != nil {
+		t.Fatalf("deleteDatasetWithRetry got err: %v", err)
+	}
+	if len(srv.requests) != 3 {
+		t.Errorf("deleteDatasetWithRetry sent %q; want 3 requests", srv.requests)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{header: "", wantOK: false},
+		{header: "3", want: 3 * time.Second, wantOK: true},
+		{header: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOK: true},
+		{header: "soon", wantOK: false},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.header != "" {
+			h.Set("Retry-After", tt.header)
+		}
+		got, ok := retryAfter(h)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("retryAfter(%q) = %v, %t; want %v, %t", tt.header, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
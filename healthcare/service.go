@@ -0,0 +1,39 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import (
+	"context"
+	"fmt"
+
+	healthcare "google.golang.org/api/healthcare/v1beta1"
+	"google.golang.org/api/option"
+)
+
+// newHealthcareService creates a Healthcare API service. With no options it
+// behaves like healthcare.NewService(ctx), using Application Default
+// Credentials and the global endpoint. opts can supply other credentials,
+// scopes, or an endpoint, such as a regional endpoint or a test server.
+//
+// The samples call healthcare.NewService inside their own regions so that
+// each one stands alone when copied. Helpers outside the regions, such as
+// deleteDatasetWithRetry, use newHealthcareService instead.
+func newHealthcareService(ctx context.Context, opts ...option.ClientOption) (*healthcare.Service, error) {
+	healthcareService, err := healthcare.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("healthcare.New: %v", err)
+	}
+	return healthcareService, nil
+}
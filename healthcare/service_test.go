@@ -0,0 +1,52 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snippets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestNewHealthcareService(t *testing.T) {
+	const name = "projects/my-project/locations/us-central1/datasets/my-dataset"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want := "/v1beta1/" + name; r.URL.Path != want {
+			http.Error(w, fmt.Sprintf("got path %q, want %q", r.URL.Path, want), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"name": %q, "timeZone": "UTC"}`, name)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	healthcareService, err := newHealthcareService(ctx, option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("newHealthcareService got err: %v", err)
+	}
+
+	dataset, err := healthcareService.Projects.Locations.Datasets.Get(name).Do()
+	if err != nil {
+		t.Fatalf("Get got err: %v", err)
+	}
+	if dataset.Name != name {
+		t.Errorf("Get got name %q, want %q", dataset.Name, name)
+	}
+}